@@ -2,9 +2,11 @@ package log
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/go-kratos/kratos/v2/log"
+	"github.com/keepitlight/kratos/runtime"
 )
 
 const (
@@ -81,3 +83,39 @@ func UrgentW(log *log.Helper, args any) {
 func FatalF(log *log.Helper, format string, args ...any) {
 	alarm(log, SeverityHigh, fmt.Sprintf(format, args...))
 }
+
+// RoutineAlarm 返回将伴生协程的错误转发到告警的回调，panic 及放弃重启为致命告警，其它错误为普通告警，
+// 通过 runtime.OnError 或 runtime.WithErrorHook 登记
+func RoutineAlarm(log *log.Helper) func(err *runtime.RoutineError) {
+	return func(err *runtime.RoutineError) {
+		switch {
+		case err.Panicked(), errors.Is(err, runtime.ErrGiveUp):
+			alarm(log, SeverityHigh, err.Error())
+		default:
+			alarm(log, SeverityLow, err.Error())
+		}
+	}
+}
+
+// Audit 返回将运行时生命周期事件记录到日志的订阅函数，失败及 panic 事件记录为错误，其它事件记录为信息，
+// 通过 runtime.Subscribe 订阅，可作为进程启动及退出过程的审计记录
+func Audit(log *log.Helper) func(e runtime.Event) {
+	return func(e runtime.Event) {
+		kv := []any{"event", e.Type.String(), "time", e.Time}
+		if e.Step != "" {
+			kv = append(kv, "step", e.Step)
+		}
+		if e.Routine != "" {
+			kv = append(kv, "routine", e.Routine, "attempt", e.Attempt)
+		}
+		switch e.Type {
+		case runtime.EventPreloadFailed, runtime.EventRoutinePanicked:
+			log.Errorw(append(kv, "error", e.Err)...)
+		default:
+			if e.Err != nil {
+				kv = append(kv, "error", e.Err)
+			}
+			log.Infow(kv...)
+		}
+	}
+}
@@ -22,19 +22,21 @@ func Join(handlers ...slog.Handler) *T {
 	}
 }
 
-func Console(level slog.Level, addSource bool) slog.Handler {
+// Console 创建控制台日志处理器，level 可以是固定的级别，也可以是 runtime.LogLevel() 以便通过信号切换调试日志
+func Console(level slog.Leveler, addSource bool) slog.Handler {
 	return slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level:     level,
 		AddSource: addSource,
 	})
 }
 
-func JSON(level slog.Level, file string, addSource bool) slog.Handler {
+// JSON 创建 JSON 格式的日志文件处理器
+func JSON(level slog.Leveler, file string, addSource bool) slog.Handler {
 	return File(level, file, true, addSource)
 }
 
 // File 创建一个日志文件处理器，将控制台和文件作为内部 Handler
-func File(level slog.Level, file string, json, addSource bool) slog.Handler {
+func File(level slog.Leveler, file string, json, addSource bool) slog.Handler {
 	logFile, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
 	if err != nil {
 		slog.Error("无法打开日志文件", "error", err)
@@ -0,0 +1,76 @@
+package runtime
+
+import (
+	"runtime/debug"
+	"strconv"
+	"time"
+)
+
+var (
+	readBuildInfo = debug.ReadBuildInfo // 便于测试替换
+)
+
+// Module 依赖模块信息
+type Module struct {
+	Path    string // 模块路径
+	Version string // 模块版本
+	Sum     string // 校验和
+	Replace *Module
+}
+
+// BuildInfo 程序的构建信息，从 debug.ReadBuildInfo 中获取
+type BuildInfo struct {
+	Revision  string    // VCS 提交版本
+	Time      time.Time // VCS 提交时间
+	Modified  bool      // 构建时工作区是否存在未提交的修改
+	GoVersion string    // 构建使用的 Go 版本
+	Path      string    // 主模块路径
+	Version   string    // 主模块版本
+	Deps      []Module  // 依赖模块
+}
+
+// ReadBuildInfo 读取当前程序的构建信息，程序未以模块方式构建时返回空值
+func ReadBuildInfo() (info BuildInfo) {
+	bi, ok := readBuildInfo()
+	if !ok || bi == nil {
+		return
+	}
+	info.GoVersion = bi.GoVersion
+	info.Path = bi.Main.Path
+	info.Version = bi.Main.Version
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time, _ = time.Parse(time.RFC3339, s.Value)
+		case "vcs.modified":
+			info.Modified, _ = strconv.ParseBool(s.Value)
+		}
+	}
+	for _, d := range bi.Deps {
+		if d != nil {
+			info.Deps = append(info.Deps, module(d))
+		}
+	}
+	return
+}
+
+func module(m *debug.Module) Module {
+	v := Module{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		r := module(m.Replace)
+		v.Replace = &r
+	}
+	return v
+}
+
+// Build 返回运行时启动时读取的构建信息
+func (r *Runtime) Build() BuildInfo {
+	return r.buildInfo
+}
+
+// Build 返回默认运行时启动时读取的构建信息
+func Build() BuildInfo {
+	return runtime.Build()
+}
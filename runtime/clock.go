@@ -0,0 +1,52 @@
+package runtime
+
+import (
+	"time"
+)
+
+// Clock 时钟，伴生协程的重启退避、定时任务及租约续约均通过时钟计时，测试时可替换为可控的时钟
+type Clock interface {
+	Now() time.Time
+	// NewTimer 创建在 d 时长后触发一次的计时器
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 计时器
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// realClock 系统时钟
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (t realTimer) C() <-chan time.Time {
+	return t.Timer.C
+}
+
+// WithClock 指定运行时的时钟，未指定时使用系统时钟
+func WithClock(clock Clock) Option {
+	return func(r *Runtime) {
+		r.clock = clock
+	}
+}
+
+// Clock 返回运行时的时钟
+func (r *Runtime) Clock() Clock {
+	if r.clock != nil {
+		return r.clock
+	}
+	return realClock{}
+}
@@ -0,0 +1,221 @@
+package runtime
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrCronSpec = errors.New("[kratos/runtime]invalid cron expression")
+)
+
+// bounds 定时表达式字段的取值范围
+type bounds struct {
+	min, max uint
+	names    map[string]uint
+}
+
+var (
+	seconds = bounds{0, 59, nil}
+	minutes = bounds{0, 59, nil}
+	hours   = bounds{0, 23, nil}
+	doms    = bounds{1, 31, nil}
+	months  = bounds{1, 12, map[string]uint{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dows = bounds{0, 6, map[string]uint{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	descriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// cron 定时表达式的调度计划，各字段以位图表示允许的取值
+type cron struct {
+	second, minute, hour, dom, month, dow uint64
+
+	anyDom, anyDow bool // 日期与星期字段是否为 *，均受限时任一匹配即可
+	loc            *time.Location
+}
+
+// ParseCron 解析标准定时表达式，支持 5 个字段（分 时 日 月 周）或 6 个字段（秒 分 时 日 月 周），
+// 支持 *、?、列表、范围、步长、月份及星期的英文缩写，以及 @yearly、@monthly、@weekly、@daily、@hourly 等描述符，
+// 可以使用 CRON_TZ=Asia/Shanghai 或 TZ=Asia/Shanghai 前缀指定时区，未指定时使用 loc，loc 为 nil 时使用本地时区
+func ParseCron(expr string, loc *time.Location) (Schedule, error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "CRON_TZ=") || strings.HasPrefix(spec, "TZ=") {
+		tz, rest, _ := strings.Cut(spec, " ")
+		_, name, _ := strings.Cut(tz, "=")
+		l, err := time.LoadLocation(name)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrCronSpec, expr, err)
+		}
+		loc, spec = l, strings.TrimSpace(rest)
+	}
+	if d, ok := descriptors[strings.ToLower(spec)]; ok {
+		spec = d
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("%w: %s: expected 5 or 6 fields", ErrCronSpec, expr)
+	}
+
+	c := &cron{loc: loc}
+	var err error
+	for i, f := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&c.second, seconds},
+		{&c.minute, minutes},
+		{&c.hour, hours},
+		{&c.dom, doms},
+		{&c.month, months},
+		{&c.dow, dows},
+	} {
+		if *f.bits, err = parseField(fields[i], f.b); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrCronSpec, expr, err)
+		}
+	}
+	c.anyDom = fields[3] == "*" || fields[3] == "?"
+	c.anyDow = fields[5] == "*" || fields[5] == "?"
+	return c, nil
+}
+
+// MustParseCron 解析定时表达式，表达式无效时 panic，适用于在 init 中登记定时任务
+func MustParseCron(expr string, loc *time.Location) Schedule {
+	s, err := ParseCron(expr, loc)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// parseField 解析单个字段，返回允许取值的位图
+func parseField(field string, b bounds) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		var lo, hi, step uint = b.min, b.max, 1
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		if hasStep {
+			if step, err = number(stepText, nil); err != nil || step == 0 {
+				return 0, fmt.Errorf("invalid step %q", part)
+			}
+		}
+		switch {
+		case rng == "*" || rng == "?":
+		default:
+			from, to, isRange := strings.Cut(rng, "-")
+			if lo, err = number(from, b.names); err != nil {
+				return 0, err
+			}
+			switch {
+			case isRange:
+				if hi, err = number(to, b.names); err != nil {
+					return 0, err
+				}
+			case !hasStep:
+				hi = lo
+			}
+		}
+		if b.max == 6 && hi == 7 { // 星期字段允许以 7 表示星期日，步进序列到达 7 时才匹配星期日
+			if lo <= 7 && (7-lo)%step == 0 {
+				bits |= 1
+			}
+			if lo == 7 {
+				continue
+			}
+			hi = 6
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d, %d] in %q", b.min, b.max, part)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func number(s string, names map[string]uint) (uint, error) {
+	if v, ok := names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.ParseUint(s, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	return uint(v), nil
+}
+
+// Next 返回晚于 t 的下一个匹配时间，五年内无匹配时返回零值
+func (c *cron) Next(t time.Time) time.Time {
+	origin := t.Location()
+	t = t.In(c.loc).Truncate(time.Second).Add(time.Second)
+	limit := t.Year() + 5
+
+wrap:
+	if t.Year() > limit {
+		return time.Time{}
+	}
+	for c.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, c.loc)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !c.day(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, c.loc)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for c.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, c.loc)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for c.minute&(1<<uint(t.Minute())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, c.loc)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for c.second&(1<<uint(t.Second())) == 0 {
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t.In(origin)
+}
+
+// day 判断日期是否匹配，日期与星期字段均受限时任一匹配即可
+func (c *cron) day(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.anyDom || c.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
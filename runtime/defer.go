@@ -0,0 +1,248 @@
+package runtime
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultShutdownTimeout Dispose 执行延迟函数的全局期限
+	DefaultShutdownTimeout = 30 * time.Second
+
+	// DefaultDrainTimeout Dispose 等待伴生协程退出的期限
+	DefaultDrainTimeout = 10 * time.Second
+)
+
+// DrainError 伴生协程未在期限内退出的错误，Routines 为仍在运行的伴生协程名称
+type DrainError struct {
+	Routines []string
+}
+
+func (e *DrainError) Error() string {
+	return fmt.Sprintf("[kratos/runtime]routines still running after drain: %s", strings.Join(e.Routines, ", "))
+}
+
+// DeferError 延迟函数失败的错误，TimedOut 表示延迟函数因超出关闭期限而未完成或未执行
+type DeferError struct {
+	Hook     string
+	Err      error
+	TimedOut bool
+}
+
+func (e *DeferError) Error() string {
+	if e.TimedOut {
+		return fmt.Sprintf("[kratos/runtime]defer %s timed out: %v", e.Hook, e.Err)
+	}
+	return fmt.Sprintf("[kratos/runtime]defer %s: %v", e.Hook, e.Err)
+}
+
+func (e *DeferError) Unwrap() error {
+	return e.Err
+}
+
+// DeferOption 延迟函数选项
+type DeferOption func(h *hook)
+
+// Priority 指定延迟函数的优先级，优先级高的先执行，相同优先级按登记的逆序（LIFO）执行，默认为 0，
+// 例如，关闭监听器的优先级应高于关闭数据库连接池
+func Priority(priority int) DeferOption {
+	return func(h *hook) {
+		h.priority = priority
+	}
+}
+
+// hook 延迟函数
+type hook struct {
+	name     string
+	fn       func(ctx context.Context) error
+	priority int
+	done     bool  // 已执行完毕
+	err      error // 执行的错误
+}
+
+// DeferStatus 延迟函数的状态
+type DeferStatus struct {
+	Name     string
+	Priority int
+	Done     bool  // 已执行完毕，未执行或超时未完成时为 false
+	Err      error // 执行失败的错误，类型为 *DeferError
+}
+
+// Defers 返回按登记顺序排列的延迟函数状态，用于确认退出时所有延迟函数均已执行
+func (r *Runtime) Defers() []DeferStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]DeferStatus, 0, len(r.defers))
+	for _, h := range r.defers {
+		list = append(list, DeferStatus{Name: h.name, Priority: h.priority, Done: h.done, Err: h.err})
+	}
+	return list
+}
+
+// call 在期限内执行延迟函数，即便延迟函数未响应上下文，超出期限后也立即返回
+func (h *hook) call(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return &DeferError{Hook: h.name, Err: err, TimedOut: true}
+	}
+	if err := Await(ctx, h.fn); err != nil {
+		return &DeferError{Hook: h.name, Err: err, TimedOut: errors.Is(err, context.DeadlineExceeded) || ctx.Err() != nil}
+	}
+	return nil
+}
+
+// Defer 登记匿名的延迟函数，运行时退出后登记返回 ErrDisposed
+func (r *Runtime) Defer(f func()) error {
+	if f == nil {
+		return nil
+	}
+	return r.DeferWith("", func(context.Context) error {
+		f()
+		return nil
+	})
+}
+
+// DeferWith 登记延迟函数，未命名的延迟函数按登记顺序自动命名，运行时退出后登记返回 ErrDisposed
+func (r *Runtime) DeferWith(name string, f func(ctx context.Context) error, options ...DeferOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.disposed {
+		return ErrDisposed
+	}
+	if f == nil {
+		return nil
+	}
+	if name == "" {
+		name = fmt.Sprintf("defer-%d", len(r.defers)+1)
+	}
+	h := &hook{name: name, fn: f}
+	for _, option := range options {
+		if option != nil {
+			option(h)
+		}
+	}
+	r.defers = append(r.defers, h)
+	return nil
+}
+
+// drain 结束运行时上下文，在排空期限及 ctx 期限内等待所有伴生协程退出，
+// 返回仍在运行的伴生协程
+func (r *Runtime) drain(ctx context.Context) error {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.closing = true
+	if r.outbox != nil {
+		r.outbox.discard()
+	}
+	r.mu.Unlock()
+	if cancel == nil {
+		return nil // 未运行
+	}
+	cancel()
+
+	timeout := r.drainTimeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	ctx, stop := context.WithTimeout(ctx, timeout)
+	defer stop()
+	done := make(chan struct{})
+	go func() {
+		r.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	var stragglers []string
+	for _, s := range r.Routines() {
+		switch s.State {
+		case StateRunning, StateRestarting, StateStandby:
+			stragglers = append(stragglers, s.Name)
+		}
+	}
+	if len(stragglers) == 0 {
+		return nil
+	}
+	return &DrainError{Routines: stragglers}
+}
+
+// DisposeContext 从注册中心注销服务实例并等待传播，结束运行时上下文并等待伴生协程退出，
+// 然后按优先级及登记的逆序执行延迟函数，仅执行一次，
+// 返回注销失败、未按期退出的伴生协程（*DrainError）及所有失败、超时的延迟函数的聚合错误
+func (r *Runtime) DisposeContext(ctx context.Context) (err error) {
+	r.exit.Do(func() {
+		r.emit(Event{Type: EventDisposeStarted})
+		logger := r.log()
+		var errs []error
+		if e := r.Deregister(ctx); e != nil {
+			e = fmt.Errorf("[kratos/runtime]deregister: %w", e)
+			logger.Errorf("%v", e)
+			errs = append(errs, e)
+		}
+		if e := r.drain(ctx); e != nil {
+			logger.Errorf("%v", e)
+			errs = append(errs, e)
+		}
+
+		r.mu.Lock()
+		r.disposed = true
+		hooks := slices.Clone(r.defers)
+		r.mu.Unlock()
+
+		slices.Reverse(hooks)
+		slices.SortStableFunc(hooks, func(a, b *hook) int {
+			return cmp.Compare(b.priority, a.priority)
+		})
+
+		for _, h := range hooks {
+			e := h.call(ctx)
+			r.mu.Lock()
+			h.done, h.err = e == nil || !e.(*DeferError).TimedOut, e
+			r.mu.Unlock()
+			if e != nil {
+				logger.Errorf("%v", e)
+				errs = append(errs, e)
+			}
+		}
+		err = errors.Join(errs...)
+		r.emit(Event{Type: EventDisposeFinished, Err: err})
+		r.events.close()
+	})
+	return
+}
+
+// Dispose 等待伴生协程退出后在关闭期限内执行延迟函数，错误仅记录日志，
+// 期限通过 WithShutdownTimeout 指定，未指定时使用 DefaultShutdownTimeout
+func (r *Runtime) Dispose() {
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+	defer cancel()
+	_ = r.DisposeContext(ctx)
+}
+
+// timeout 返回执行延迟函数的期限
+func (r *Runtime) timeout() time.Duration {
+	if r.shutdownTimeout > 0 {
+		return r.shutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
+
+// DeferWith 指定在主程序退出时执行的具名函数，函数接收带有关闭期限的上下文，返回的错误将被汇总并记录日志，
+// 通过 Priority 选项指定执行顺序，运行时退出后调用返回 ErrDisposed
+func DeferWith(name string, f func(ctx context.Context) error, options ...DeferOption) error {
+	return runtime.DeferWith(name, f, options...)
+}
+
+// DisposeContext 在上下文期限内执行所有延迟函数，返回的聚合错误中包含 *DeferError，
+// 其 TimedOut 字段指示延迟函数是否超时
+func DisposeContext(ctx context.Context) error {
+	return runtime.DisposeContext(ctx)
+}
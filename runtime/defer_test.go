@@ -0,0 +1,126 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDisposeOrder(t *testing.T) {
+	var order []string
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			order = append(order, name)
+			return nil
+		}
+	}
+	r := New()
+	r.Defer(func() { order = append(order, "log") })
+	r.DeferWith("db", record("db"))
+	r.DeferWith("listener", record("listener"), Priority(10))
+	r.DeferWith("cache", record("cache"))
+
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"listener", "cache", "db", "log"}; !slices.Equal(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+}
+
+func TestDisposeTimeout(t *testing.T) {
+	failure := errors.New("failure")
+	r := New()
+	r.DeferWith("after", func(context.Context) error { return nil })
+	r.DeferWith("stuck", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	r.DeferWith("broken", func(context.Context) error { return failure })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	err := r.DisposeContext(ctx)
+	if !errors.Is(err, failure) {
+		t.Errorf("error %v does not contain hook failure", err)
+	}
+	var timedOut []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var de *DeferError
+		if errors.As(e, &de) && de.TimedOut {
+			timedOut = append(timedOut, de.Hook)
+		}
+	}
+	if want := []string{"stuck", "after"}; !slices.Equal(timedOut, want) {
+		t.Errorf("timed out hooks = %v, want %v", timedOut, want)
+	}
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Errorf("second dispose returned %v", err)
+	}
+}
+
+func TestDisposeDrain(t *testing.T) {
+	var closed atomic.Bool
+	var drained atomic.Bool
+	r := New(WithDrainTimeout(20 * time.Millisecond))
+	r.CoWith(func(ctx context.Context) error {
+		<-ctx.Done()
+		drained.Store(!closed.Load())
+		return nil
+	}, Name("writer"))
+	r.CoWith(func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}, Name("stuck"))
+	r.DeferWith("db", func(context.Context) error {
+		closed.Store(true)
+		return nil
+	})
+	c := r.run(context.Background())
+	go func() {
+		for range c {
+		}
+	}()
+
+	err := r.DisposeContext(context.Background())
+	var de *DrainError
+	if !errors.As(err, &de) || !slices.Equal(de.Routines, []string{"stuck"}) {
+		t.Fatalf("error = %v, want DrainError reporting stuck", err)
+	}
+	if !drained.Load() || !closed.Load() {
+		t.Errorf("writer drained = %t, db closed = %t, want both", drained.Load(), closed.Load())
+	}
+}
+
+func TestDisposeUnreadChannel(t *testing.T) {
+	r := New(WithDrainTimeout(time.Second))
+	r.CoWith(func(ctx context.Context) error {
+		<-ctx.Done()
+		return errors.New("flush failed") // 退出时返回错误，但无人读取消息通道
+	}, Name("writer"))
+	r.run(context.Background())
+
+	begin := time.Now()
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(begin); d >= time.Second {
+		t.Errorf("dispose took %v, want less than drain timeout", d)
+	}
+	if s := r.Routines()[0]; s.State != StateFailed {
+		t.Errorf("writer state = %v, want failed", s.State)
+	}
+}
+
+func TestDisposePanic(t *testing.T) {
+	r := New()
+	r.DeferWith("cache", func(context.Context) error { panic("boom") })
+	err := r.DisposeContext(context.Background())
+	var de *DeferError
+	if !errors.As(err, &de) || de.Hook != "cache" || de.TimedOut || !errors.Is(err, ErrPanic) {
+		t.Errorf("error = %v, want DeferError wrapping ErrPanic", err)
+	}
+}
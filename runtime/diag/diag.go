@@ -0,0 +1,234 @@
+package diag
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	goruntime "runtime"
+	"strings"
+	"time"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+	"github.com/keepitlight/kratos/runtime"
+)
+
+const (
+	Path = "/debug/runtime" // 诊断信息的默认路径
+)
+
+// App 主程序信息
+type App struct {
+	ID        string            `json:"id"`
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Endpoints []string          `json:"endpoints,omitempty"`
+}
+
+// Build 构建信息
+type Build struct {
+	Build     string    `json:"build,omitempty"`  // 构建时间
+	Commit    string    `json:"commit,omitempty"` // 提交版本
+	Modified  bool      `json:"modified,omitempty"`
+	Time      time.Time `json:"time,omitzero"`
+	GoVersion string    `json:"goVersion,omitempty"`
+	Path      string    `json:"path,omitempty"`
+	Version   string    `json:"version,omitempty"`
+}
+
+// Memory 内存统计
+type Memory struct {
+	Alloc      uint64 `json:"alloc"`
+	TotalAlloc uint64 `json:"totalAlloc"`
+	Sys        uint64 `json:"sys"`
+	HeapAlloc  uint64 `json:"heapAlloc"`
+	HeapInuse  uint64 `json:"heapInuse"`
+	HeapObject uint64 `json:"heapObjects"`
+	NumGC      uint32 `json:"numGC"`
+	PauseTotal string `json:"pauseTotal"`
+}
+
+// Routine 伴生协程状态
+type Routine struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Critical    bool              `json:"critical,omitempty"`
+	Group       string            `json:"group,omitempty"`
+	State       string            `json:"state"`
+	Started     time.Time         `json:"started,omitzero"`
+	Restarts    int               `json:"restarts,omitempty"`
+	LastError   string            `json:"lastError,omitempty"`
+	Stack       string            `json:"stack,omitempty"`
+
+	Runs         int       `json:"runs,omitempty"`
+	Skipped      int       `json:"skipped,omitempty"`
+	LastRun      time.Time `json:"lastRun,omitzero"`
+	LastDuration string    `json:"lastDuration,omitempty"`
+	LastRunError string    `json:"lastRunError,omitempty"`
+	NextRun      time.Time `json:"nextRun,omitzero"`
+}
+
+// Document 运行时诊断信息
+type Document struct {
+	App        *App              `json:"app,omitempty"`
+	Build      Build             `json:"build"`
+	Started    time.Time         `json:"started,omitzero"`
+	Uptime     string            `json:"uptime,omitempty"`
+	Scene      runtime.SceneType `json:"scene"`
+	Ready      bool              `json:"ready"`
+	Goroutines int               `json:"goroutines"`
+	Memory     Memory            `json:"memory"`
+	Tuning     *runtime.Tuning   `json:"tuning,omitempty"`
+	Routines   []Routine         `json:"routines"`
+}
+
+// Collect 收集运行时 r 的诊断信息
+func Collect(r *runtime.Runtime) Document {
+	build, commit, started := r.State()
+	bi := r.Build()
+	doc := Document{
+		Build: Build{
+			Build:     build,
+			Commit:    commit,
+			Modified:  bi.Modified,
+			Time:      bi.Time,
+			GoVersion: bi.GoVersion,
+			Path:      bi.Path,
+			Version:   bi.Version,
+		},
+		Started:    started,
+		Scene:      r.Scene(),
+		Ready:      r.Ready(),
+		Goroutines: goruntime.NumGoroutine(),
+		Routines:   []Routine{},
+	}
+	if !started.IsZero() {
+		doc.Uptime = time.Since(started).Round(time.Second).String()
+	}
+	if t := r.Tuning(); t.GOMAXPROCS > 0 {
+		doc.Tuning = &t
+	}
+	if info := r.AppInfo(); info != nil {
+		doc.App = &App{
+			ID:        info.ID(),
+			Name:      info.Name(),
+			Version:   info.Version(),
+			Metadata:  info.Metadata(),
+			Endpoints: info.Endpoint(),
+		}
+	}
+
+	var m goruntime.MemStats
+	goruntime.ReadMemStats(&m)
+	doc.Memory = Memory{
+		Alloc:      m.Alloc,
+		TotalAlloc: m.TotalAlloc,
+		Sys:        m.Sys,
+		HeapAlloc:  m.HeapAlloc,
+		HeapInuse:  m.HeapInuse,
+		HeapObject: m.HeapObjects,
+		NumGC:      m.NumGC,
+		PauseTotal: time.Duration(m.PauseTotalNs).String(),
+	}
+
+	for _, s := range r.Routines() {
+		v := Routine{
+			Name:        s.Name,
+			Description: s.Description,
+			Labels:      s.Labels,
+			Critical:    s.Critical,
+			Group:       s.Group,
+			State:       s.State.String(),
+			Started:     s.Started,
+			Restarts:    s.Restarts,
+			Stack:       string(s.Stack),
+			Runs:        s.Runs,
+			Skipped:     s.Skipped,
+			LastRun:     s.LastRun,
+			NextRun:     s.NextRun,
+		}
+		if s.LastError != nil {
+			v.LastError = s.LastError.Error()
+		}
+		if !s.LastRun.IsZero() {
+			v.LastDuration = s.LastDuration.String()
+		}
+		if s.LastRunError != nil {
+			v.LastRunError = s.LastRunError.Error()
+		}
+		doc.Routines = append(doc.Routines, v)
+	}
+	return doc
+}
+
+// Option 诊断信息处理器选项
+type Option func(o *options)
+
+type options struct {
+	authenticate func(r *http.Request) bool
+	addr         string // 诊断服务的监听地址
+	enabled      bool   // 预生产及生产场景下显式启用诊断服务
+}
+
+// Authenticate 指定访问诊断信息的认证函数，认证失败时响应 401
+func Authenticate(f func(r *http.Request) bool) Option {
+	return func(o *options) {
+		o.authenticate = f
+	}
+}
+
+// Token 使用固定的 Bearer 令牌认证访问诊断信息
+func Token(token string) Option {
+	return Authenticate(func(r *http.Request) bool {
+		v, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return ok && token != "" && subtle.ConstantTimeCompare([]byte(v), []byte(token)) == 1
+	})
+}
+
+// Handler 返回以 JSON 格式输出运行时 r 的诊断信息的 HTTP 处理器，
+// 生产场景（runtime.SceneRel）下未指定认证函数时禁用，响应 404
+func Handler(r *runtime.Runtime, opts ...Option) http.Handler {
+	return newOptions(opts).guard(r, document(r))
+}
+
+// newOptions 解析选项
+func newOptions(opts []Option) *options {
+	o := &options{addr: DefaultAddr}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(o)
+		}
+	}
+	return o
+}
+
+// document 以 JSON 格式输出运行时 r 的诊断信息
+func document(r *runtime.Runtime) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(Collect(r))
+	})
+}
+
+// guard 认证访问，生产场景下未指定认证函数时响应 404，认证失败时响应 401
+func (o *options) guard(r *runtime.Runtime, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if o.authenticate == nil {
+			if r.Scene() == runtime.SceneRel {
+				http.NotFound(w, req)
+				return
+			}
+		} else if !o.authenticate(req) {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
+}
+
+// Register 在 kratos HTTP 服务上挂载运行时 r 的诊断信息处理器，路径为 /debug/runtime
+func Register(srv *khttp.Server, r *runtime.Runtime, opts ...Option) {
+	srv.Handle(Path, Handler(r, opts...))
+}
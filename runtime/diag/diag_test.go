@@ -0,0 +1,181 @@
+package diag_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keepitlight/kratos/runtime"
+	"github.com/keepitlight/kratos/runtime/diag"
+)
+
+func TestHandler(t *testing.T) {
+	r := runtime.New()
+	r.CoWith(func(ctx context.Context) error { return nil }, runtime.Name("worker"))
+
+	serve := func(h http.Handler, token string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, diag.Path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve(diag.Handler(r), "")
+	var doc diag.Document
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(doc.Routines) != 1 || doc.Routines[0].Name != "worker" || doc.Goroutines == 0 {
+		t.Errorf("unexpected document: %d %+v", w.Code, doc)
+	}
+
+	defer func(s runtime.SceneType) { runtime.Scene = s }(runtime.Scene)
+	runtime.Scene = runtime.SceneRel
+	if w := serve(diag.Handler(r), ""); w.Code != http.StatusNotFound {
+		t.Errorf("release without authentication: %d", w.Code)
+	}
+	if w := serve(diag.Handler(r, diag.Token("secret")), "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong token: %d", w.Code)
+	}
+	if w := serve(diag.Handler(r, diag.Token("secret")), "secret"); w.Code != http.StatusOK {
+		t.Errorf("valid token: %d", w.Code)
+	}
+}
+
+// freeAddr 返回一个空闲的本机监听地址
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+// exited 启动运行时并等待诊断服务伴生协程退出，返回期间报告的错误
+func exited(t *testing.T, r *runtime.Runtime) []error {
+	t.Helper()
+	c, _, _ := r.Start(context.Background(), nil, nil, "", "", time.Now())
+	deadline := time.Now().Add(time.Second)
+	for s := r.Routines()[0]; s.State != runtime.StateStopped && s.State != runtime.StateFailed; s = r.Routines()[0] {
+		if time.Now().After(deadline) {
+			t.Fatalf("diag routine did not exit: %+v", s)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// 释放运行时会丢弃未读取的错误，须在释放前读取
+	var errs []error
+	for {
+		select {
+		case e := <-c:
+			errs = append(errs, e)
+			continue
+		case <-time.After(50 * time.Millisecond):
+		}
+		break
+	}
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return errs
+}
+
+func TestServeScene(t *testing.T) {
+	rel := runtime.New(runtime.WithScene(runtime.SceneRel))
+	diag.Serve(rel, diag.Enable())
+	if errs := exited(t, rel); len(errs) != 1 || !errors.Is(errs[0], diag.ErrAuthRequired) {
+		t.Errorf("release without authentication: %v", errs)
+	}
+
+	rel = runtime.New(runtime.WithScene(runtime.SceneRel))
+	diag.Serve(rel, diag.Token("secret"))
+	if errs := exited(t, rel); len(errs) != 0 {
+		t.Errorf("release without opt-in: %v", errs)
+	}
+
+	// 场景在启动时确定，注册后修改场景同样生效
+	addr := freeAddr(t)
+	r := runtime.New(runtime.WithScene(runtime.SceneTest))
+	diag.Serve(r, diag.Addr(addr))
+	if err := r.SetScene(runtime.SceneRel); err != nil {
+		t.Fatal(err)
+	}
+	if errs := exited(t, r); len(errs) != 0 {
+		t.Errorf("scene changed to release: %v", errs)
+	}
+}
+
+func TestServeAddrInUse(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	r := runtime.New(runtime.WithScene(runtime.SceneTest))
+	diag.Serve(r, diag.Addr(ln.Addr().String()))
+	errs := exited(t, r)
+	if len(errs) != 1 {
+		t.Errorf("errors = %v, want exactly one bind error", errs)
+	}
+	if s := r.Routines()[0]; s.State != runtime.StateFailed || s.Restarts != 0 {
+		t.Errorf("diag routine after bind failure: %+v", s)
+	}
+}
+
+func TestServe(t *testing.T) {
+	addr := freeAddr(t)
+	r := runtime.New(runtime.WithScene(runtime.SceneTest))
+	diag.Serve(r, diag.Addr(addr))
+	c, _, _ := r.Start(context.Background(), nil, nil, "", "", time.Now())
+	go func() {
+		for e := range c {
+			t.Errorf("unexpected error: %v", e)
+		}
+	}()
+
+	var (
+		resp *http.Response
+		err  error
+	)
+	for i := 0; i < 100; i++ {
+		if resp, err = http.Get("http://" + addr + "/debug/goroutines"); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine ") {
+		t.Errorf("goroutines: %d %.100s", resp.StatusCode, body)
+	}
+	if resp, err := http.Get("http://" + addr + "/debug/vars"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("expvar: %v", err)
+	} else {
+		resp.Body.Close()
+	}
+
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if s := r.Routines()[0]; s.Name != diag.RoutineName || s.State != runtime.StateStopped {
+		t.Errorf("diag routine after dispose: %+v", s)
+	}
+	if _, err := http.Get("http://" + addr + "/debug/vars"); err == nil {
+		t.Error("diagnostics server still listening after dispose")
+	}
+}
@@ -0,0 +1,113 @@
+package diag
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"time"
+
+	"github.com/keepitlight/kratos/runtime"
+)
+
+const (
+	// DefaultAddr 诊断服务的默认监听地址，仅本机可访问
+	DefaultAddr = "127.0.0.1:6060"
+	// RoutineName 诊断服务伴生协程的名称
+	RoutineName = "diag"
+)
+
+var (
+	// ShutdownTimeout 诊断服务关闭时等待进行中请求完成的期限
+	ShutdownTimeout = 5 * time.Second
+
+	ErrAuthRequired = errors.New("[kratos/runtime]diagnostics server requires authentication in pre-production and release scenes")
+)
+
+// Addr 指定诊断服务的监听地址，未指定时为 DefaultAddr
+func Addr(addr string) Option {
+	return func(o *options) {
+		o.addr = addr
+	}
+}
+
+// Enable 在演示、预生产及生产场景下显式启用诊断服务，预生产及生产场景还须指定认证函数
+func Enable() Option {
+	return func(o *options) {
+		o.enabled = true
+	}
+}
+
+// Mux 返回诊断服务的 HTTP 处理器，所有路径均需通过认证：
+//
+//   - /debug/runtime 运行时诊断信息
+//   - /debug/pprof/ net/http/pprof 性能分析，其中 /debug/pprof/trace?seconds=N 按需采集 runtime/trace
+//   - /debug/vars expvar 变量
+//   - /debug/goroutines 所有协程的堆栈
+func Mux(r *runtime.Runtime, opts ...Option) http.Handler {
+	return newOptions(opts).mux(r)
+}
+
+// mux 创建诊断服务的 HTTP 处理器，由 guard 统一认证
+func (o *options) mux(r *runtime.Runtime) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(Path, document(r))
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/goroutines", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write(runtime.Stacks())
+	})
+	return o.guard(r, mux)
+}
+
+// Serve 以伴生协程的方式启动诊断服务，诊断服务随运行时的退出而关闭，是否启用在伴生协程启动时按运行时的场景决定：
+// 开发及测试场景下默认启用；其它场景须通过 Enable 显式启用，预生产及生产场景还须指定认证函数，否则报告 ErrAuthRequired，
+// 未启用时伴生协程直接退出；诊断服务不重启，监听失败（例如端口被占用）等错误仅通过运行时的消息通道报告一次
+func Serve(r *runtime.Runtime, opts ...Option) {
+	o := newOptions(opts)
+	handler := o.mux(r)
+	r.CoWith(func(ctx context.Context) error {
+		if enabled, err := o.check(r.Scene()); !enabled {
+			return err
+		}
+		ln, err := net.Listen("tcp", o.addr)
+		if err != nil {
+			return err
+		}
+		srv := &http.Server{Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+		done := make(chan error, 1)
+		go func() {
+			done <- srv.Serve(ln)
+		}()
+		select {
+		case err = <-done:
+			return err
+		case <-ctx.Done():
+		}
+		sctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), ShutdownTimeout)
+		defer cancel()
+		return srv.Shutdown(sctx)
+	}, runtime.Name(RoutineName), runtime.Describe("diagnostics server on "+o.addr))
+}
+
+// check 判断诊断服务在场景 scene 下是否启用，预生产及生产场景启用但未指定认证函数时返回 ErrAuthRequired
+func (o *options) check(scene runtime.SceneType) (bool, error) {
+	switch scene {
+	case runtime.SceneDev, runtime.SceneTest:
+		return true, nil
+	}
+	if !o.enabled {
+		return false, nil
+	}
+	if (scene == runtime.ScenePre || scene == runtime.SceneRel) && o.authenticate == nil {
+		return false, ErrAuthRequired
+	}
+	return true, nil
+}
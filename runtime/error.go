@@ -0,0 +1,128 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	// ErrPanic 伴生协程、定时任务或 Await 执行的函数发生 panic，RoutineError 的 Err 包装此错误
+	ErrPanic = errors.New("[kratos/runtime]panic catch")
+)
+
+// RoutineError 伴生协程失败的错误，发送到运行时的消息通道，可通过 errors.As 获取，
+// 返回的错误、panic 及放弃重启均以此类型报告
+type RoutineError struct {
+	Routine string    // 伴生协程名称
+	Err     error     // 原因，panic 时包装 ErrPanic
+	Panic   any       // panic 的值，未发生 panic 时为 nil
+	Stack   []byte    // panic 时的堆栈
+	Time    time.Time // 发生时间
+	Attempt int       // 第几次执行（从 1 开始），定时任务为第几次运行
+}
+
+func (e *RoutineError) Error() string {
+	if e.Panic != nil {
+		return fmt.Sprintf("[kratos/runtime]routine %s panic: %v\n\n%s", e.Routine, e.Panic, e.Stack)
+	}
+	return fmt.Sprintf("[kratos/runtime]routine %s: %v", e.Routine, e.Err)
+}
+
+func (e *RoutineError) Unwrap() error {
+	return e.Err
+}
+
+// Panicked 是否因 panic 而失败
+func (e *RoutineError) Panicked() bool {
+	return e.Panic != nil
+}
+
+// panicked 将 panic 的值 p 转换为包装 ErrPanic 的错误
+func panicked(p any) error {
+	if e, ok := p.(error); ok {
+		return fmt.Errorf("%w: %w", ErrPanic, e)
+	}
+	return fmt.Errorf("%w: %v", ErrPanic, p)
+}
+
+// protect 执行 f，将 panic 转换为包含 panic 值及堆栈的 *RoutineError 返回
+func protect(f func() error) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = &RoutineError{Err: panicked(p), Panic: p, Stack: debug.Stack()}
+		}
+	}()
+	return f()
+}
+
+// Await 在新的协程中执行 f，f 返回或 ctx 结束时返回，即便 f 未响应上下文也不等待其完成，
+// f 发生 panic 时返回包装 ErrPanic 的错误，用于执行预加载步骤、延迟函数及健康检查等外部函数
+func Await(ctx context.Context, f func(ctx context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- panicked(p)
+			}
+		}()
+		done <- f(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// wrap 将 err 包装为附带伴生协程名称、时间及执行次数的 *RoutineError
+func (t *task) wrap(err error, attempt int) *RoutineError {
+	var e *RoutineError
+	if p, ok := err.(*RoutineError); ok && p.Routine == "" {
+		e = p
+	} else {
+		e = &RoutineError{Err: err}
+	}
+	e.Routine = t.status.Name
+	e.Time = t.clock.Now()
+	e.Attempt = attempt
+	return e
+}
+
+// WithErrorHook 指定伴生协程失败时的回调，回调在伴生协程所在的协程中同步执行，
+// 可用于将错误转发到告警，例如 log.RoutineAlarm
+func WithErrorHook(f func(err *RoutineError)) Option {
+	return func(r *Runtime) {
+		if f != nil {
+			r.hooks = append(r.hooks, f)
+		}
+	}
+}
+
+// OnError 增加伴生协程失败时的回调
+func (r *Runtime) OnError(f func(err *RoutineError)) {
+	if f == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, f)
+}
+
+// report 执行伴生协程失败时的回调
+func (r *Runtime) report(err *RoutineError) {
+	r.mu.Lock()
+	hooks := r.hooks
+	r.mu.Unlock()
+	for _, f := range hooks {
+		f(err)
+	}
+}
+
+// OnError 为默认运行时增加伴生协程失败时的回调
+func OnError(f func(err *RoutineError)) {
+	runtime.OnError(f)
+}
@@ -0,0 +1,180 @@
+package runtime
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// EventType 运行时生命周期事件的类型
+type EventType int
+
+const (
+	EventPreloadStarted   EventType = iota + 1 // 预加载开始，Step 为空时表示整个预加载阶段
+	EventPreloadFinished                       // 预加载完成，Step 为空时表示整个预加载阶段
+	EventPreloadFailed                         // 预加载失败，Step 为空时表示整个预加载阶段
+	EventAppStarted                            // 主程序开始运行，随后启动伴生协程
+	EventRoutineStarted                        // 伴生协程开始一次运行
+	EventRoutineExited                         // 伴生协程退出且不再重启
+	EventRoutinePanicked                       // 伴生协程发生 panic
+	EventRoutineRestarted                      // 伴生协程在退避后重启
+	EventDisposeStarted                        // 开始退出
+	EventDisposeFinished                       // 退出完成，此后不再发布事件
+)
+
+var eventNames = [...]string{
+	EventPreloadStarted:   "preload.started",
+	EventPreloadFinished:  "preload.finished",
+	EventPreloadFailed:    "preload.failed",
+	EventAppStarted:       "app.started",
+	EventRoutineStarted:   "routine.started",
+	EventRoutineExited:    "routine.exited",
+	EventRoutinePanicked:  "routine.panicked",
+	EventRoutineRestarted: "routine.restarted",
+	EventDisposeStarted:   "dispose.started",
+	EventDisposeFinished:  "dispose.finished",
+}
+
+func (t EventType) String() string {
+	if t > 0 && int(t) < len(eventNames) {
+		return eventNames[t]
+	}
+	return fmt.Sprintf("EventType(%d)", int(t))
+}
+
+// Event 运行时生命周期事件
+type Event struct {
+	Type    EventType
+	Time    time.Time
+	Step    string // 预加载步骤名称，仅预加载事件
+	Routine string // 伴生协程名称，仅伴生协程事件
+	Attempt int    // 伴生协程第几次运行（从 1 开始），仅伴生协程事件
+	Err     error  // 失败、panic 或伴生协程退出的错误
+}
+
+// SubscribeOption 订阅选项
+type SubscribeOption func(s *subscriber)
+
+// Async 指定为异步订阅，事件进入容量为 buffer 的队列，由独立的协程依次处理，
+// 队列已满时丢弃事件，不阻塞发布者
+func Async(buffer int) SubscribeOption {
+	return func(s *subscriber) {
+		s.queue = make(chan Event, max(buffer, 1))
+	}
+}
+
+// Only 指定仅订阅这些类型的事件
+func Only(types ...EventType) SubscribeOption {
+	return func(s *subscriber) {
+		s.types = append(s.types, types...)
+	}
+}
+
+// subscriber 事件订阅者
+type subscriber struct {
+	fn    func(e Event)
+	types []EventType
+	queue chan Event // 异步订阅的队列，同步订阅为 nil
+}
+
+// deliver 执行订阅函数，订阅函数的 panic 不影响发布者
+func (s *subscriber) deliver(e Event) {
+	defer func() { _ = recover() }()
+	s.fn(e)
+}
+
+// bus 生命周期事件总线
+type bus struct {
+	mu     sync.Mutex
+	subs   []*subscriber
+	closed bool
+}
+
+// publish 发布事件，同步订阅者在发布者的协程中依次执行
+func (b *bus) publish(e Event) {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	var inline []*subscriber
+	for _, s := range b.subs {
+		if len(s.types) > 0 && !slices.Contains(s.types, e.Type) {
+			continue
+		}
+		if s.queue == nil {
+			inline = append(inline, s)
+			continue
+		}
+		select {
+		case s.queue <- e:
+		default: // 队列已满，丢弃
+		}
+	}
+	b.mu.Unlock()
+	for _, s := range inline {
+		s.deliver(e)
+	}
+}
+
+// remove 移除订阅者，异步订阅的队列随之关闭，调用方需持有锁
+func (b *bus) remove(s *subscriber) {
+	if i := slices.Index(b.subs, s); i >= 0 {
+		b.subs = slices.Delete(b.subs, i, i+1)
+		if s.queue != nil {
+			close(s.queue)
+		}
+	}
+}
+
+// close 关闭事件总线，异步订阅者处理完队列中的事件后退出
+func (b *bus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for _, s := range slices.Clone(b.subs) {
+		b.remove(s)
+	}
+}
+
+// Subscribe 订阅运行时的生命周期事件，默认为同步订阅，订阅函数在发布事件的协程中执行，应尽快返回，
+// 返回取消订阅的函数，运行时退出完成后订阅自动取消
+func (r *Runtime) Subscribe(f func(e Event), options ...SubscribeOption) (cancel func()) {
+	s := &subscriber{fn: f}
+	for _, option := range options {
+		if option != nil {
+			option(s)
+		}
+	}
+	b := &r.events
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if f == nil || b.closed {
+		return func() {}
+	}
+	b.subs = append(b.subs, s)
+	if s.queue != nil {
+		go func(queue <-chan Event) {
+			for e := range queue {
+				s.deliver(e)
+			}
+		}(s.queue)
+	}
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.remove(s)
+	}
+}
+
+// emit 以运行时的时钟发布事件
+func (r *Runtime) emit(e Event) {
+	e.Time = r.Clock().Now()
+	r.events.publish(e)
+}
+
+// Subscribe 订阅默认运行时的生命周期事件
+func Subscribe(f func(e Event), options ...SubscribeOption) (cancel func()) {
+	return runtime.Subscribe(f, options...)
+}
@@ -0,0 +1,57 @@
+package runtime
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEvents(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	r := New()
+	r.Subscribe(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e.Type.String()+":"+e.Step+e.Routine)
+	})
+	async := make(chan Event, 10)
+	r.Subscribe(func(e Event) { async <- e }, Async(4), Only(EventRoutinePanicked))
+	exited := make(chan struct{})
+	r.Subscribe(func(e Event) { close(exited) }, Only(EventRoutineExited))
+	r.PreloadWith("db", func(context.Context) error { return nil })
+	var calls int
+	r.CoWith(func(ctx context.Context) error {
+		if calls++; calls == 1 {
+			panic("boom")
+		}
+		return nil
+	}, Name("worker"), Restart(RestartOnFailure), WithBackoff(Backoff{}))
+
+	c, err, _ := r.Start(context.Background(), nil, nil, "", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for range c {
+		}
+	}()
+	if e := <-async; e.Routine != "worker" || e.Attempt != 1 || e.Err == nil {
+		t.Errorf("unexpected async event: %+v", e)
+	}
+	<-exited
+	r.DisposeContext(context.Background())
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"preload.started:", "preload.started:db", "preload.finished:db", "preload.finished:",
+		"app.started:", "routine.started:worker", "routine.panicked:worker", "routine.restarted:worker",
+		"routine.started:worker", "routine.exited:worker", "dispose.started:", "dispose.finished:",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
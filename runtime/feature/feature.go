@@ -0,0 +1,439 @@
+// Package feature 提供基于场景的功能开关，开关在代码中声明并按场景指定默认值，
+// 可通过 kratos 配置及环境变量覆盖，支持按稳定的键（例如 JWT 的 Subject）按百分比灰度启用，
+// 开关变化可通过 Watch 观察
+package feature
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/keepitlight/kratos/jwt"
+	"github.com/keepitlight/kratos/runtime"
+)
+
+const (
+	// EnvPrefix 默认读取开关的环境变量前缀，例如 FEATURE_VERBOSE_TRACING=true 或 FEATURE_NEW_CHECKOUT=25%
+	EnvPrefix = "FEATURE_"
+	// ConfigKey 默认读取开关的配置键，值为开关名称到开关值的映射
+	ConfigKey = "features"
+)
+
+var (
+	ErrUndefined = errors.New("[kratos/runtime]feature flag undefined")
+	ErrValue     = errors.New("[kratos/runtime]invalid feature flag value")
+
+	std = NewRegistry() // 默认的开关注册表
+)
+
+// State 开关状态，Enabled 为 false 时关闭，Percent 为灰度启用的百分比，100 表示全部启用
+type State struct {
+	Enabled bool
+	Percent int
+}
+
+func (s State) String() string {
+	switch {
+	case !s.Enabled:
+		return "off"
+	case s.Percent >= 100:
+		return "on"
+	default:
+		return fmt.Sprintf("%d%%", s.Percent)
+	}
+}
+
+// Parse 解析开关值，支持 true、false、on、off、1、0 等布尔值及 25% 形式的灰度百分比
+func Parse(value string) (State, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch value {
+	case "on", "yes":
+		return State{Enabled: true, Percent: 100}, nil
+	case "off", "no":
+		return State{}, nil
+	}
+	if p, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 || n > 100 {
+			return State{}, fmt.Errorf("%w: %q", ErrValue, value)
+		}
+		return State{Enabled: n > 0, Percent: n}, nil
+	}
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return State{}, fmt.Errorf("%w: %q", ErrValue, value)
+	}
+	if on {
+		return State{Enabled: true, Percent: 100}, nil
+	}
+	return State{}, nil
+}
+
+// Option 开关选项
+type Option func(f *Flag)
+
+// Default 指定未按场景指定时的默认值
+func Default(on bool) Option {
+	return func(f *Flag) {
+		f.fallback = on
+	}
+}
+
+// On 指定在这些场景中默认启用
+func On(scenes ...runtime.SceneType) Option {
+	return func(f *Flag) {
+		for _, s := range scenes {
+			f.scenes[s] = true
+		}
+	}
+}
+
+// Off 指定在这些场景中默认关闭
+func Off(scenes ...runtime.SceneType) Option {
+	return func(f *Flag) {
+		for _, s := range scenes {
+			f.scenes[s] = false
+		}
+	}
+}
+
+// Rollout 指定默认启用时的灰度百分比，按上下文中的键稳定地决定是否启用
+func Rollout(percent int) Option {
+	return func(f *Flag) {
+		f.percent = min(max(percent, 0), 100)
+	}
+}
+
+// Flag 功能开关
+type Flag struct {
+	name     string
+	registry *Registry
+	fallback bool
+	scenes   map[runtime.SceneType]bool
+	percent  int
+}
+
+// Name 返回开关名称
+func (f *Flag) Name() string {
+	return f.name
+}
+
+// State 返回开关的当前状态，按 Set、环境变量、配置、场景默认值的优先级确定
+func (f *Flag) State() State {
+	return f.registry.state(f)
+}
+
+// Enabled 返回开关在上下文 ctx 中是否启用，灰度启用时按上下文中的键决定，无键时视为未启用
+func (f *Flag) Enabled(ctx context.Context) bool {
+	s := f.State()
+	if !s.Enabled {
+		return false
+	}
+	if s.Percent >= 100 {
+		return true
+	}
+	key := f.registry.key(ctx)
+	if key == "" {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(f.name + ":" + key))
+	return int(h.Sum32()%100) < s.Percent
+}
+
+// KeyFunc 从上下文中获取灰度键
+type KeyFunc func(ctx context.Context) string
+
+type keyContext struct{}
+
+// WithKey 返回携带灰度键的上下文
+func WithKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, keyContext{}, key)
+}
+
+// KeyFromContext 返回通过 WithKey 设置的灰度键
+func KeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(keyContext{}).(string)
+	return key
+}
+
+// Subject 返回以 JWT 的 Subject 作为灰度键的函数，请求中无凭证时使用 WithKey 设置的键
+func Subject(parser *jwt.Parser) KeyFunc {
+	return func(ctx context.Context) string {
+		if claims, err := parser.Lookup(ctx); err == nil && claims != nil && claims.Subject != "" {
+			return claims.Subject
+		}
+		return KeyFromContext(ctx)
+	}
+}
+
+// RegistryOption 开关注册表选项
+type RegistryOption func(r *Registry)
+
+// WithKeyFunc 指定获取灰度键的函数，未指定时使用 KeyFromContext
+func WithKeyFunc(f KeyFunc) RegistryOption {
+	return func(r *Registry) {
+		r.keyFunc = f
+	}
+}
+
+// WithScene 指定获取场景的函数，未指定时使用默认运行时的场景
+func WithScene(f func() runtime.SceneType) RegistryOption {
+	return func(r *Registry) {
+		r.scene = f
+	}
+}
+
+type watch struct {
+	name string
+	fn   func(s State)
+}
+
+// Registry 开关注册表
+type Registry struct {
+	keyFunc KeyFunc
+	scene   func() runtime.SceneType
+
+	mu      sync.RWMutex
+	flags   map[string]*Flag
+	manual  map[string]State // 通过 Set 设置
+	env     map[string]State // 来自环境变量
+	conf    map[string]State // 来自配置
+	watches map[int]watch
+	seq     int
+}
+
+// NewRegistry 创建开关注册表
+func NewRegistry(options ...RegistryOption) *Registry {
+	r := &Registry{
+		keyFunc: KeyFromContext,
+		scene:   runtime.CurrentScene,
+		flags:   make(map[string]*Flag),
+		manual:  make(map[string]State),
+		env:     make(map[string]State),
+		conf:    make(map[string]State),
+		watches: make(map[int]watch),
+	}
+	for _, option := range options {
+		if option != nil {
+			option(r)
+		}
+	}
+	return r
+}
+
+func (r *Registry) key(ctx context.Context) string {
+	if r.keyFunc == nil {
+		return ""
+	}
+	return r.keyFunc(ctx)
+}
+
+// Define 声明开关，重复声明时返回已声明的开关
+func (r *Registry) Define(name string, options ...Option) *Flag {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.flags[name]; ok {
+		return f
+	}
+	f := &Flag{name: name, registry: r, scenes: make(map[runtime.SceneType]bool), percent: 100}
+	for _, option := range options {
+		if option != nil {
+			option(f)
+		}
+	}
+	r.flags[name] = f
+	return f
+}
+
+// Lookup 查找已声明的开关
+func (r *Registry) Lookup(name string) (*Flag, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.flags[name]
+	return f, ok
+}
+
+// Enabled 返回开关在上下文 ctx 中是否启用，未声明的开关视为关闭
+func (r *Registry) Enabled(ctx context.Context, name string) bool {
+	f, ok := r.Lookup(name)
+	return ok && f.Enabled(ctx)
+}
+
+func (r *Registry) state(f *Flag) State {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resolve(f)
+}
+
+// resolve 确定开关的状态，调用方需持有锁
+func (r *Registry) resolve(f *Flag) State {
+	for _, layer := range []map[string]State{r.manual, r.env, r.conf} {
+		if s, ok := layer[f.name]; ok {
+			return s
+		}
+	}
+	on, ok := f.scenes[r.scene()]
+	if !ok {
+		on = f.fallback
+	}
+	if !on {
+		return State{}
+	}
+	return State{Enabled: f.percent > 0, Percent: f.percent}
+}
+
+// apply 修改开关状态并通知状态发生变化的观察者
+func (r *Registry) apply(change func()) {
+	r.mu.Lock()
+	before := make(map[string]State, len(r.flags))
+	for name, f := range r.flags {
+		before[name] = r.resolve(f)
+	}
+	change()
+	var notify []func()
+	for name, f := range r.flags {
+		if s := r.resolve(f); s != before[name] {
+			for _, w := range r.watches {
+				if w.name == name {
+					notify = append(notify, func() { w.fn(s) })
+				}
+			}
+		}
+	}
+	r.mu.Unlock()
+	for _, n := range notify {
+		n()
+	}
+}
+
+// Set 覆盖开关状态，优先级最高，用于测试或运行中的人工干预
+func (r *Registry) Set(name string, s State) error {
+	if _, ok := r.Lookup(name); !ok {
+		return fmt.Errorf("%w: %s", ErrUndefined, name)
+	}
+	r.apply(func() { r.manual[name] = s })
+	return nil
+}
+
+// Reset 撤销 Set 的覆盖
+func (r *Registry) Reset(name string) {
+	r.apply(func() { delete(r.manual, name) })
+}
+
+// LoadEnv 从环境变量中加载开关值，开关名称转换为大写，- 及 . 替换为 _，例如 verbose-tracing 对应 FEATURE_VERBOSE_TRACING，
+// prefix 为空时使用 EnvPrefix
+func (r *Registry) LoadEnv(prefix string) error {
+	if prefix == "" {
+		prefix = EnvPrefix
+	}
+	r.mu.RLock()
+	names := make([]string, 0, len(r.flags))
+	for name := range r.flags {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	states := make(map[string]State)
+	var errs []error
+	for _, name := range names {
+		key := prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+		if v, ok := os.LookupEnv(key); ok {
+			s, err := Parse(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", key, err))
+				continue
+			}
+			states[name] = s
+		}
+	}
+	r.apply(func() { r.env = states })
+	return errors.Join(errs...)
+}
+
+// LoadConfig 从 kratos 配置中加载开关值并观察配置变化，key 为空时使用 ConfigKey，
+// 配置值为开关名称到开关值的映射，例如 features: {verbose-tracing: false, new-checkout: 25%}
+func (r *Registry) LoadConfig(c config.Config, key string) error {
+	if key == "" {
+		key = ConfigKey
+	}
+	if err := r.loadValue(c.Value(key)); err != nil {
+		return err
+	}
+	return c.Watch(key, func(_ string, v config.Value) {
+		_ = r.loadValue(v)
+	})
+}
+
+func (r *Registry) loadValue(v config.Value) error {
+	values, err := v.Map()
+	if err != nil {
+		return nil // 未配置
+	}
+	states := make(map[string]State, len(values))
+	var errs []error
+	for name, value := range values {
+		text, err := value.String()
+		if err == nil {
+			var s State
+			if s, err = Parse(text); err == nil {
+				states[name] = s
+				continue
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", name, err))
+	}
+	r.apply(func() { r.conf = states })
+	return errors.Join(errs...)
+}
+
+// Watch 观察开关状态的变化，返回取消观察的函数，回调在修改开关的协程中同步执行
+func (r *Registry) Watch(name string, f func(s State)) (cancel func()) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	id := r.seq
+	r.watches[id] = watch{name: name, fn: f}
+	return func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		delete(r.watches, id)
+	}
+}
+
+// States 返回所有开关的当前状态
+func (r *Registry) States() map[string]State {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	states := make(map[string]State, len(r.flags))
+	for name, f := range r.flags {
+		states[name] = r.resolve(f)
+	}
+	return states
+}
+
+// Std 返回默认的开关注册表
+func Std() *Registry {
+	return std
+}
+
+// Define 在默认注册表中声明开关，通常在包级变量中声明
+func Define(name string, options ...Option) *Flag {
+	return std.Define(name, options...)
+}
+
+// Enabled 返回默认注册表中的开关在上下文 ctx 中是否启用
+func Enabled(ctx context.Context, name string) bool {
+	return std.Enabled(ctx, name)
+}
+
+// Watch 观察默认注册表中开关状态的变化
+func Watch(name string, f func(s State)) (cancel func()) {
+	return std.Watch(name, f)
+}
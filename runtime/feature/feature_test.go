@@ -0,0 +1,67 @@
+package feature
+
+import (
+	"context"
+	"testing"
+
+	"github.com/keepitlight/kratos/runtime"
+)
+
+func TestFlag(t *testing.T) {
+	scene := runtime.SceneDev
+	r := NewRegistry(WithScene(func() runtime.SceneType { return scene }))
+	tracing := r.Define("verbose-tracing", On(runtime.SceneDev, runtime.SceneTest))
+	checkout := r.Define("new-checkout", Default(true), Rollout(30))
+
+	ctx := context.Background()
+	if !tracing.Enabled(ctx) {
+		t.Error("verbose-tracing disabled in dev")
+	}
+	scene = runtime.SceneRel
+	if tracing.Enabled(ctx) {
+		t.Error("verbose-tracing enabled in rel")
+	}
+
+	if checkout.Enabled(ctx) {
+		t.Error("rollout enabled without key")
+	}
+	var enabled int
+	for i := range 1000 {
+		key := WithKey(ctx, "user-"+string(rune('a'+i%26))+string(rune('a'+i/26)))
+		if checkout.Enabled(key) {
+			enabled++
+		}
+		if checkout.Enabled(key) != r.Enabled(key, "new-checkout") {
+			t.Fatal("rollout is not stable")
+		}
+	}
+	if enabled < 200 || enabled > 400 {
+		t.Errorf("rollout enabled %d of 1000, want about 300", enabled)
+	}
+
+	var changes []State
+	cancel := r.Watch("verbose-tracing", func(s State) { changes = append(changes, s) })
+	t.Setenv("FEATURE_VERBOSE_TRACING", "on")
+	if err := r.LoadEnv(""); err != nil {
+		t.Fatal(err)
+	}
+	r.Set("verbose-tracing", State{})
+	r.Set("verbose-tracing", State{})
+	cancel()
+	r.Reset("verbose-tracing")
+	if len(changes) != 2 || !changes[0].Enabled || changes[1].Enabled {
+		t.Errorf("changes = %v, want [on off]", changes)
+	}
+	if !tracing.Enabled(ctx) {
+		t.Error("env override not applied after reset")
+	}
+
+	for in, want := range map[string]State{"true": {true, 100}, "OFF": {}, "25%": {true, 25}, "0%": {}} {
+		if s, err := Parse(in); err != nil || s != want {
+			t.Errorf("Parse(%q) = %v, %v, want %v", in, s, err, want)
+		}
+	}
+	if _, err := Parse("150%"); err == nil {
+		t.Error("Parse(150%) succeeded")
+	}
+}
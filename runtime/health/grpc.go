@@ -0,0 +1,106 @@
+package health
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	kgrpc "github.com/go-kratos/kratos/v2/transport/grpc"
+	"github.com/keepitlight/kratos/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ReasonTrailer Check 未通过时附带未通过原因的 trailer 键，每行一个未通过的检查
+	ReasonTrailer = "health-reason-bin"
+)
+
+var (
+	// WatchInterval gRPC Watch 轮询健康状态的间隔
+	WatchInterval = 5 * time.Second
+)
+
+// server 实现 grpc.health.v1 健康检查服务，服务名为空时执行就绪检查，
+// 服务名为登记的检查名称时仅执行该检查
+type server struct {
+	grpc_health_v1.UnimplementedHealthServer
+	health *Health
+}
+
+// GRPC 返回 grpc.health.v1 健康检查服务的实现
+func (h *Health) GRPC() grpc_health_v1.HealthServer {
+	return &server{health: h}
+}
+
+// RegisterGRPC 在 kratos gRPC 服务上注册健康检查服务，
+// 服务须以 grpc.CustomHealth() 选项创建，以禁用 kratos 内置的健康检查服务
+func (h *Health) RegisterGRPC(srv *kgrpc.Server) {
+	grpc_health_v1.RegisterHealthServer(srv, h.GRPC())
+}
+
+// status 返回服务的健康状态，未通过时返回未通过的检查及原因
+func (s *server) status(ctx context.Context, service string) (grpc_health_v1.HealthCheckResponse_ServingStatus, []string, error) {
+	if service == "" {
+		report := s.health.Ready(ctx)
+		if report.Status == StatusUp {
+			return grpc_health_v1.HealthCheckResponse_SERVING, nil, nil
+		}
+		reasons := make([]string, 0, len(report.Checks))
+		for name, reason := range report.Checks {
+			reasons = append(reasons, name+": "+reason)
+		}
+		slices.Sort(reasons)
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, reasons, nil
+	}
+	c, ok := s.health.lookup(service)
+	if !ok {
+		return grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN, nil, status.Error(codes.NotFound, "unknown service")
+	}
+	if s.health.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.health.timeout)
+		defer cancel()
+	}
+	if err := runtime.Await(ctx, c); err != nil {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING, []string{service + ": " + err.Error()}, nil
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING, nil, nil
+}
+
+func (s *server) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
+	v, reasons, err := s.status(ctx, req.GetService())
+	if err != nil {
+		return nil, err
+	}
+	if len(reasons) > 0 {
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(ReasonTrailer, strings.Join(reasons, "\n")))
+	}
+	return &grpc_health_v1.HealthCheckResponse{Status: v}, nil
+}
+
+func (s *server) Watch(req *grpc_health_v1.HealthCheckRequest, stream grpc_health_v1.Health_WatchServer) error {
+	ctx := stream.Context()
+	ticker := time.NewTicker(WatchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_ServingStatus(-1)
+	for {
+		v, _, _ := s.status(ctx, req.GetService())
+		if v != last {
+			if err := stream.Send(&grpc_health_v1.HealthCheckResponse{Status: v}); err != nil {
+				return err
+			}
+			last = v
+		}
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
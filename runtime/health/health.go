@@ -0,0 +1,199 @@
+package health
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/keepitlight/kratos/runtime"
+)
+
+// Status 健康状态
+type Status string
+
+const (
+	StatusUp       Status = "UP"       // 正常
+	StatusDegraded Status = "DEGRADED" // 降级，关键伴生协程已失败
+	StatusDown     Status = "DOWN"     // 不可用
+)
+
+var (
+	// DefaultTimeout 单次健康检查的默认超时时间
+	DefaultTimeout = 3 * time.Second
+
+	std = &Health{current: runtime.Current, timeout: DefaultTimeout} // 基于默认运行时的健康检查，每次检查时获取默认运行时
+)
+
+// Check 健康检查函数，返回错误表示检查未通过
+type Check func(ctx context.Context) error
+
+// Report 健康检查报告
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"` // 未通过的检查及原因
+}
+
+func (r *Report) fail(name, reason string) {
+	if r.Checks == nil {
+		r.Checks = make(map[string]string)
+	}
+	r.Checks[name] = reason
+}
+
+type check struct {
+	name string
+	fn   Check
+}
+
+// Option 健康检查选项
+type Option func(h *Health)
+
+// Timeout 指定单次健康检查的超时时间
+func Timeout(d time.Duration) Option {
+	return func(h *Health) {
+		h.timeout = d
+	}
+}
+
+// Health 基于运行时状态的健康检查，存活检查仅执行登记的存活检查函数，
+// 就绪检查在预加载步骤全部完成前始终未就绪，关键伴生协程失败时状态降级
+type Health struct {
+	current func() *runtime.Runtime
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+}
+
+// New 创建运行时 r 的健康检查
+func New(r *runtime.Runtime, options ...Option) *Health {
+	h := &Health{current: func() *runtime.Runtime { return r }, timeout: DefaultTimeout}
+	for _, option := range options {
+		if option != nil {
+			option(h)
+		}
+	}
+	return h
+}
+
+// AddLiveness 登记存活检查函数
+func (h *Health) AddLiveness(name string, c Check) {
+	if c == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.liveness = append(h.liveness, check{name: name, fn: c})
+}
+
+// AddReadiness 登记就绪检查函数
+func (h *Health) AddReadiness(name string, c Check) {
+	if c == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.readiness = append(h.readiness, check{name: name, fn: c})
+}
+
+// lookup 查找指定名称的检查函数
+func (h *Health) lookup(name string) (Check, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, c := range slices.Concat(h.readiness, h.liveness) {
+		if c.name == name {
+			return c.fn, true
+		}
+	}
+	return nil, false
+}
+
+// run 并行执行检查函数，将未通过的检查记录到报告中
+func (h *Health) run(ctx context.Context, checks []check, report *Report) {
+	if len(checks) == 0 {
+		return
+	}
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+	errs := make([]error, len(checks))
+	var wg sync.WaitGroup
+	wg.Add(len(checks))
+	for i, c := range checks {
+		go func() {
+			defer wg.Done()
+			errs[i] = runtime.Await(ctx, c.fn) // 检查函数未响应上下文时超时后立即返回
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			report.Status = StatusDown
+			report.fail(checks[i].name, err.Error())
+		}
+	}
+}
+
+// Live 执行存活检查
+func (h *Health) Live(ctx context.Context) Report {
+	h.mu.RLock()
+	checks := slices.Clone(h.liveness)
+	h.mu.RUnlock()
+
+	report := Report{Status: StatusUp}
+	h.run(ctx, checks, &report)
+	return report
+}
+
+// Ready 执行就绪检查，预加载步骤未全部完成时未就绪，预加载失败时报告失败原因，关键伴生协程失败时状态降级
+func (h *Health) Ready(ctx context.Context) Report {
+	report := Report{Status: StatusUp}
+	r := h.current()
+	if !r.Ready() {
+		report.Status = StatusDown
+		reason := "pending"
+		if err := r.PreloadFailure(); err != nil {
+			reason = err.Error()
+		}
+		report.fail("preload", reason)
+		return report
+	}
+
+	h.mu.RLock()
+	checks := slices.Clone(h.readiness)
+	h.mu.RUnlock()
+	h.run(ctx, checks, &report)
+
+	for _, s := range r.Routines() {
+		if s.Critical && s.State == runtime.StateFailed {
+			if report.Status == StatusUp {
+				report.Status = StatusDegraded
+			}
+			reason := s.State.String()
+			if s.LastError != nil {
+				reason = s.LastError.Error()
+			}
+			report.fail("routine:"+s.Name, reason)
+		}
+	}
+	return report
+}
+
+// Default 返回基于默认运行时的健康检查
+func Default() *Health {
+	return std
+}
+
+// Liveness 为默认运行时登记存活检查函数
+func Liveness(name string, c Check) {
+	std.AddLiveness(name, c)
+}
+
+// Readiness 为默认运行时登记就绪检查函数
+func Readiness(name string, c Check) {
+	std.AddReadiness(name, c)
+}
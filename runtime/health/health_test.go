@@ -0,0 +1,182 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keepitlight/kratos/runtime"
+	"github.com/keepitlight/kratos/runtime/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func get(t *testing.T, h http.Handler) (int, health.Report) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	var report health.Report
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	return w.Code, report
+}
+
+func TestReadiness(t *testing.T) {
+	r := runtime.New()
+	failed := make(chan struct{})
+	r.CoWith(func(ctx context.Context) error {
+		defer close(failed)
+		return errors.New("consumer lost")
+	}, runtime.Name("consumer"), runtime.Critical())
+	h := health.New(r)
+
+	if code, report := get(t, h.ReadinessHandler()); code != http.StatusServiceUnavailable || report.Checks["preload"] == "" {
+		t.Errorf("before start: %d %+v", code, report)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c, err, _ := r.Start(ctx, nil, nil, "", "", time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-c
+	<-failed
+	for r.Routines()[0].State != runtime.StateFailed {
+		time.Sleep(time.Millisecond)
+	}
+	if code, report := get(t, h.ReadinessHandler()); code != http.StatusServiceUnavailable ||
+		report.Status != health.StatusDegraded || report.Checks["routine:consumer"] == "" {
+		t.Errorf("critical routine failed: %d %+v", code, report)
+	}
+	if code, report := get(t, h.LivenessHandler()); code != http.StatusOK || report.Status != health.StatusUp {
+		t.Errorf("liveness: %d %+v", code, report)
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	h := health.New(runtime.New(), health.Timeout(10*time.Millisecond))
+	h.AddLiveness("ok", func(ctx context.Context) error { return nil })
+	h.AddLiveness("stuck", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	code, report := get(t, h.LivenessHandler())
+	if code != http.StatusServiceUnavailable || report.Status != health.StatusDown || len(report.Checks) != 1 {
+		t.Errorf("liveness: %d %+v", code, report)
+	}
+}
+
+// dial 通过 bufconn 启动 grpc.health.v1 服务并返回客户端
+func dial(t *testing.T, h *health.Health) grpc_health_v1.HealthClient {
+	t.Helper()
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(srv, h.GRPC())
+	go srv.Serve(ln)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return grpc_health_v1.NewHealthClient(conn)
+}
+
+func TestGRPC(t *testing.T) {
+	interval := health.WatchInterval
+	health.WatchInterval = 5 * time.Millisecond
+	defer func() { health.WatchInterval = interval }()
+
+	r := runtime.New()
+	h := health.New(r)
+	h.AddReadiness("db", func(ctx context.Context) error { return nil })
+	h.AddReadiness("cache", func(ctx context.Context) error { return errors.New("cache down") })
+	client := dial(t, h)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		t.Helper()
+		res, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("check %q: %v", service, err)
+		}
+		return res.GetStatus()
+	}
+	if s := check(""); s != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("before start = %v, want NOT_SERVING", s)
+	}
+	if s := check("db"); s != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("db = %v, want SERVING", s)
+	}
+	if s := check("cache"); s != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("cache = %v, want NOT_SERVING", s)
+	}
+	if _, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("missing service error = %v, want NotFound", err)
+	}
+
+	unknown, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{Service: "missing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := unknown.Recv(); err != nil || res.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN {
+		t.Errorf("watch missing = %v, %v, want SERVICE_UNKNOWN", res, err)
+	}
+
+	// 运行时启动前预加载未完成，Watch 先报告 NOT_SERVING，启动后转为 SERVING
+	h = health.New(r)
+	h.AddReadiness("db", func(ctx context.Context) error { return nil })
+	client = dial(t, h)
+	stream, err := client.Watch(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res, err := stream.Recv(); err != nil || res.GetStatus() != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("watch before start = %v, %v, want NOT_SERVING", res, err)
+	}
+	if _, err, _ := r.Start(ctx, nil, nil, "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := stream.Recv(); err != nil || res.GetStatus() != grpc_health_v1.HealthCheckResponse_SERVING {
+		t.Errorf("watch after start = %v, %v, want SERVING", res, err)
+	}
+}
+
+func TestPreloadFailure(t *testing.T) {
+	r := runtime.New()
+	_ = r.PreloadWith("db", func(context.Context) error { return errors.New("db unreachable") })
+	previous := runtime.Replace(r) // 默认的健康检查跟随替换后的默认运行时
+	defer runtime.Replace(previous)
+	if _, err, _ := runtime.Start(context.Background(), nil, nil, "", "", time.Now()); err == nil {
+		t.Fatal("start succeeded, want preload failure")
+	}
+
+	if code, report := get(t, health.Default().ReadinessHandler()); code != http.StatusServiceUnavailable ||
+		!strings.Contains(report.Checks["preload"], "db unreachable") {
+		t.Errorf("readiness: %d %+v", code, report)
+	}
+	var trailer metadata.MD
+	res, err := dial(t, health.Default()).Check(context.Background(), &grpc_health_v1.HealthCheckRequest{}, grpc.Trailer(&trailer))
+	if err != nil || res.GetStatus() != grpc_health_v1.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("check = %v, %v, want NOT_SERVING", res, err)
+	}
+	if reason := strings.Join(trailer.Get(health.ReasonTrailer), ""); !strings.Contains(reason, "preload: ") || !strings.Contains(reason, "db unreachable") {
+		t.Errorf("reason trailer = %q, want preload failure", reason)
+	}
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	khttp "github.com/go-kratos/kratos/v2/transport/http"
+)
+
+const (
+	LivenessPath  = "/healthz" // 存活检查路径
+	ReadinessPath = "/readyz"  // 就绪检查路径
+)
+
+// LivenessHandler 返回存活检查的 HTTP 处理器，存活时响应 200，否则响应 503
+func (h *Health) LivenessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, h.Live(r.Context()))
+	})
+}
+
+// ReadinessHandler 返回就绪检查的 HTTP 处理器，就绪时响应 200，未就绪或降级时响应 503
+func (h *Health) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		write(w, h.Ready(r.Context()))
+	})
+}
+
+// Register 在 kratos HTTP 服务上挂载 /healthz 及 /readyz
+func (h *Health) Register(srv *khttp.Server) {
+	srv.Handle(LivenessPath, h.LivenessHandler())
+	srv.Handle(ReadinessPath, h.ReadinessHandler())
+}
+
+func write(w http.ResponseWriter, report Report) {
+	code := http.StatusOK
+	if report.Status != StatusUp {
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -2,12 +2,17 @@ package runtime
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"os"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/log"
 	"github.com/go-kratos/kratos/v2/registry"
 )
 
@@ -16,27 +21,112 @@ const (
 )
 
 var (
-	runtime = &Runtime{once: sync.Once{}, exit: sync.Once{}}
+	runtime = New() // 默认运行时，包级函数均作用于默认运行时
+
+	ErrStarted  = errors.New("[kratos/runtime]runtime already started")
+	ErrDisposed = errors.New("[kratos/runtime]runtime already disposed")
 )
 
 // Runtime 运行时
 type Runtime struct {
-	readies  []func() error // 准备程序
-	defers   []func()       // 延迟程序
-	routines []Routine      // 伴生协程
+	readies  []*step     // 预加载步骤
+	defers   []*hook     // 延迟程序
+	routines []*task     // 伴生协程
+	reloads  []*reloader // 重新加载函数
 
 	appInfo   kratos.AppInfo     // 当前的主程序信息，仅在主程序运行后被设置为有效信息
 	registrar registry.Registrar // 当前的注册中心
 	build     string             // 构建时间信息
 	commit    string             // 提交版本信息
 	uptime    time.Time          // 程序开始的运行时间
+	buildInfo BuildInfo          // 构建信息
+
+	logger          log.Logger                // 日志记录器，未指定时使用 kratos 全局日志记录器
+	shutdownTimeout time.Duration             // Dispose 执行延迟函数的期限
+	drainTimeout    time.Duration             // Dispose 等待伴生协程退出的期限
+	propagation     time.Duration             // 注销服务实例后等待传播的时长
+	metadata        map[string]string         // 运行中更新的服务实例元数据
+	scene           SceneType                 // 场景，启动后固定
+	clock           Clock                     // 时钟，未指定时使用系统时钟
+	events          bus                       // 生命周期事件总线
+	tuning          Tuning                    // 自动调优的结果
+	sceneSet        bool                      // 已指定场景
+	hooks           []func(err *RoutineError) // 伴生协程失败时的回调
+	policy          FailurePolicy             // 伴生协程失败时的处理策略
+	quorums         map[string]int            // 伴生协程组的法定数量
+	signals         <-chan os.Signal          // 注入的信号来源，未指定时监听操作系统信号
+	level           *slog.LevelVar            // 由信号切换的日志级别
+	base            slog.Level                // 切换到调试级别前的日志级别
+
+	mu           sync.Mutex         // 保护登记及运行状态
+	publish      sync.Mutex         // 串行化注册中心的调用，调用期间不持有 mu
+	started      bool               // 已启动，不再接受预加载步骤
+	ready        atomic.Bool        // 预加载步骤已全部成功完成
+	loadErr      error              // 预加载失败的错误
+	disposed     bool               // 已开始退出，不再接受延迟函数
+	closing      bool               // 运行时上下文已结束，不再启动伴生协程
+	halted       bool               // 已因失败策略关闭
+	deregistered bool               // 已从注册中心注销
+	ctx          context.Context    // 伴生协程的运行上下文，运行后有效
+	cancel       context.CancelFunc // 结束运行时上下文，运行后有效
+	outbox       *outbox            // 伴生协程消息通道的发送队列，运行后有效
+	wg           sync.WaitGroup     // 运行中的伴生协程
+	seq          int                // 伴生协程的登记序号
 
 	once sync.Once
 	exit sync.Once
 }
 
-// start 启动运行时，⚠️仅运行一次
-func (r *Runtime) start(
+// Option 运行时选项
+type Option func(r *Runtime)
+
+// WithLogger 指定运行时的日志记录器
+func WithLogger(logger log.Logger) Option {
+	return func(r *Runtime) {
+		r.logger = logger
+	}
+}
+
+// WithShutdownTimeout 指定 Dispose 执行延迟函数的期限，未指定时使用 DefaultShutdownTimeout
+func WithShutdownTimeout(d time.Duration) Option {
+	return func(r *Runtime) {
+		r.shutdownTimeout = d
+	}
+}
+
+// WithDrainTimeout 指定 Dispose 等待伴生协程退出的期限，未指定时使用 DefaultDrainTimeout，
+// 超出期限仍未退出的伴生协程以 *DrainError 报告，随后照常执行延迟函数
+func WithDrainTimeout(d time.Duration) Option {
+	return func(r *Runtime) {
+		r.drainTimeout = d
+	}
+}
+
+// New 创建独立的运行时，各运行时的伴生协程、预加载步骤及延迟函数相互隔离，
+// 适用于测试或在同一进程中运行多个主程序
+func New(options ...Option) *Runtime {
+	r := &Runtime{}
+	for _, option := range options {
+		if option != nil {
+			option(r)
+		}
+	}
+	return r
+}
+
+// log 返回运行时的日志帮助器
+func (r *Runtime) log() *log.Helper {
+	logger := r.logger
+	if logger == nil {
+		logger = log.GetLogger()
+	}
+	return log.NewHelper(log.With(logger, "module", Package))
+}
+
+// Start 启动运行时，⚠️仅运行一次
+// 如需关闭伴生协程，传递可以取消的上下文，通过上下文关闭伴生协程，
+// 返回的消息通道在上下文结束且所有伴生协程退出后关闭
+func (r *Runtime) Start(
 	ctx context.Context,
 	appInfo kratos.AppInfo,
 	registrar registry.Registrar,
@@ -44,18 +134,8 @@ func (r *Runtime) start(
 	uptime time.Time) (msg <-chan error, err error, ok bool) {
 
 	r.once.Do(func() {
-		// msg = make(chan error)
-		r.appInfo = appInfo
-		r.registrar = registrar
-		r.build = build
-		r.commit = commit
-		r.uptime = uptime
-		// 预加载的函数
-		for _, ready := range r.readies {
-			err = ready()
-			if err != nil {
-				return
-			}
+		if err = r.prepare(ctx, appInfo, registrar, build, commit, uptime); err != nil {
+			return
 		}
 		msg = r.run(ctx)
 		ok = true
@@ -63,6 +143,61 @@ func (r *Runtime) start(
 	return
 }
 
+// prepare 记录主程序信息并按依赖关系执行预加载步骤，
+// build、commit 为空时使用构建信息中的 VCS 提交时间及提交版本
+func (r *Runtime) prepare(
+	ctx context.Context,
+	appInfo kratos.AppInfo,
+	registrar registry.Registrar,
+	build, commit string,
+	uptime time.Time) error {
+
+	r.mu.Lock()
+	r.started = true
+	if !r.sceneSet {
+		r.scene = Scene
+	}
+	r.mu.Unlock()
+
+	r.buildInfo = ReadBuildInfo()
+	if build == "" && !r.buildInfo.Time.IsZero() {
+		build = r.buildInfo.Time.Format(time.RFC3339)
+	}
+	if commit == "" {
+		commit = r.buildInfo.Revision
+	}
+
+	r.appInfo = appInfo
+	r.registrar = registrar
+	r.build = build
+	r.commit = commit
+	r.uptime = uptime
+	r.emit(Event{Type: EventPreloadStarted})
+	if err := r.load(ctx); err != nil {
+		r.mu.Lock()
+		r.loadErr = err
+		r.mu.Unlock()
+		r.emit(Event{Type: EventPreloadFailed, Err: err})
+		return err
+	}
+	r.ready.Store(true)
+	r.emit(Event{Type: EventPreloadFinished})
+	return nil
+}
+
+// Ready 返回预加载步骤是否已全部成功完成
+func (r *Runtime) Ready() bool {
+	return r.ready.Load()
+}
+
+// PreloadFailure 返回预加载失败的错误，未启动、执行中或成功时返回 nil
+func (r *Runtime) PreloadFailure() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.loadErr
+}
+
+// State 返回运行时的构建时间、提交版本及启动时间
 func (r *Runtime) State() (build, commit string, uptime time.Time) {
 	return r.build, r.commit, r.uptime
 }
@@ -75,79 +210,150 @@ func (r *Runtime) Registrar() registry.Registrar {
 	return r.registrar
 }
 
-// Preload 指定在主程序启动时执行的函数，此方法要在 init 函数中调用，否则可能会被忽略
-func (r *Runtime) preload(f func() error) {
-	r.readies = append(r.readies, f)
+// Co 增加伴生协程，以在主协程启动时执行，伴生协程退出或异常不影响主协程，
+// 但主协程退出或异常，伴生协程收到上下文退出通知要主动退出，运行后增加的伴生协程立即启动
+func (r *Runtime) Co(routines ...Routine) {
+	routines = slices.DeleteFunc(routines, func(r Routine) bool { return r == nil })
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, routine := range routines {
+		r.add(newTask(routine))
+	}
 }
 
-// Defer 指定在主程序退出时执行的函数，全局延迟函数
-func (r *Runtime) deferIt(f func()) {
-	r.defers = append(r.defers, f)
+// CoWith 增加受监管的伴生协程，按指定的重启策略执行，运行后增加的伴生协程立即启动
+func (r *Runtime) CoWith(routine Routine, options ...RoutineOption) {
+	if routine == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(newTask(routine, options...))
 }
 
-// Co 增加伴生协程，以在主协程启动时执行，伴生协程退出或异常不影响主协程，
-// 但主协程退出或异常，伴生协程收到上下文退出通知要主动退出，注意，在 init 中调用，
-// 否则会被忽略
-func (r *Runtime) co(routines ...Routine) {
-	routines = slices.DeleteFunc(routines, func(r Routine) bool { return r == nil })
-	r.routines = append(r.routines, routines...)
+// add 登记伴生协程，未命名的伴生协程按登记序号自动命名，运行后登记的伴生协程立即启动，
+// 调用方需持有锁
+func (r *Runtime) add(t *task) {
+	r.seq++ // 伴生协程可被移除，序号单调递增以保证名称唯一
+	if t.status.Name == "" {
+		t.status.Name = fmt.Sprintf("routine-%d", r.seq)
+	}
+	t.report = r.report
+	t.clock = r.Clock()
+	t.emit = r.emit
+	t.warn = func(format string, args ...any) { r.log().Warnf(format, args...) }
+	r.routines = append(r.routines, t)
+	if r.ctx != nil {
+		r.spawn(t)
+	}
 }
 
-// run 执行所有注册的伴生协程，与主协程协同运行，伴生协程退出或异常不影响主协程，
-// 返回带缓冲的消息通道，返回通道关闭表示所有伴生协程正常退出。
-// 但主协程退出或异常，伴生协程收到通知要主动退出
-func (r *Runtime) run(ctx context.Context) <-chan error {
-	var c = make(chan error)
+// spawn 在运行时上下文的子上下文中启动伴生协程，调用方需持有锁
+func (r *Runtime) spawn(t *task) {
+	if r.closing {
+		t.status.update(func(s *RoutineStatus) { s.State = StateStopped })
+		return
+	}
+	ctx, cancel := context.WithCancel(r.ctx)
+	t.cancel = cancel
+	r.wg.Add(1)
+	outbox := r.outbox
+	send := func(err error) {
+		if !outbox.send(err) {
+			r.log().Warnf("[kratos/runtime]message channel full, dropped: %v", err)
+		}
+	}
 	go func() {
-		var wg sync.WaitGroup
-		wg.Add(len(r.routines))
-		for _, ro := range r.routines {
-			go func(routine Routine) {
-				defer func() {
-					wg.Done()
-
-					if p := recover(); p != nil {
-						// 意外的 panic，打印堆栈信息
-						c <- fmt.Errorf("[kratos/runtime]panic catch, routine throw error:\n%v\n\n", p)
-					}
-				}()
-
-				if e := routine(ctx); e != nil {
-					c <- e
-				}
-			}(ro)
+		defer r.wg.Done()
+		defer cancel()
+		t.supervise(ctx, send)
+		r.settle(ctx, t, send)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		t.cancel = nil
+		if t.retired {
+			r.remove(t)
 		}
-		wg.Wait()
-		close(c)
 	}()
-	return c
 }
 
-func (r *Runtime) dispose() {
-	r.exit.Do(func() {
-		for _, def := range r.defers {
-			def()
-		}
-	})
+// remove 移除伴生协程，调用方需持有锁
+func (r *Runtime) remove(t *task) {
+	if i := slices.Index(r.routines, t); i >= 0 {
+		r.routines = slices.Delete(r.routines, i, i+1)
+	}
+}
+
+// Routines 返回所有伴生协程的状态快照
+func (r *Runtime) Routines() []RoutineStatus {
+	r.mu.Lock()
+	tasks := slices.Clone(r.routines)
+	r.mu.Unlock()
+
+	list := make([]RoutineStatus, 0, len(tasks))
+	for _, t := range tasks {
+		list = append(list, t.status.snapshot())
+	}
+	return list
+}
+
+// run 执行所有注册的伴生协程，与主协程协同运行，伴生协程退出或异常不影响主协程，
+// 返回消息通道，运行时上下文结束且所有伴生协程退出后通道关闭。
+// 但主协程退出或异常，伴生协程收到通知要主动退出，失败策略触发关闭时运行时上下文提前结束
+func (r *Runtime) run(ctx context.Context) <-chan error {
+	o := newOutbox()
+	r.mu.Lock()
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.mu.Unlock()
+	// 先发布启动事件再启动伴生协程，保证订阅者观察到的事件顺序确定；
+	// 期间增加的伴生协程在运行时上下文设置后随其它伴生协程一并启动
+	r.emit(Event{Type: EventAppStarted})
+	r.mu.Lock()
+	r.ctx, r.outbox = ctx, o
+	for _, t := range r.routines {
+		r.spawn(t)
+	}
+	r.mu.Unlock()
+	go r.watch(ctx)
+
+	go func() {
+		<-ctx.Done()
+		r.mu.Lock()
+		r.closing = true
+		r.mu.Unlock()
+		r.wg.Wait()
+		o.seal()
+	}()
+	return o.c
 }
 
 // Co 增加伴生协程，以在主协程启动时执行，伴生协程退出或异常不影响主协程，
-// 但主协程退出或异常，伴生协程收到通知要主动退出，注意，在 init 中调用，否则会被忽略
+// 但主协程退出或异常，伴生协程收到通知要主动退出，运行时启动后增加的伴生协程立即启动
 func Co(routines ...Routine) {
-	runtime.co(routines...)
+	runtime.Co(routines...)
+}
+
+// CoWith 增加受监管的伴生协程，通过选项指定重启策略、退避及重启预算，
+// 超出重启预算时向消息通道发送包装了 ErrGiveUp 的错误，运行时启动后增加的伴生协程立即启动
+func CoWith(routine Routine, options ...RoutineOption) {
+	runtime.CoWith(routine, options...)
 }
 
 // Start 启动运行时，⚠️仅运行一次
-// 如需关闭伴生协程，传递可以取消的上下文，通过上下文关闭伴生协程
+// 如需关闭伴生协程，传递可以取消的上下文，通过上下文关闭伴生协程，
+// 返回的消息通道在上下文结束且所有伴生协程退出后关闭，
+// build、commit 为空时从 debug.ReadBuildInfo 中获取，完整的构建信息通过 Build 获取
 func Start(
 	ctx context.Context,
 	appInfo kratos.AppInfo,
 	registrar registry.Registrar,
 	build, commit string,
 	uptime time.Time) (channel <-chan error, err error, ok bool) {
-	return runtime.start(ctx, appInfo, registrar, build, commit, uptime)
+	return runtime.Start(ctx, appInfo, registrar, build, commit, uptime)
 }
 
+// State 返回默认运行时的主程序信息、注册中心、构建时间、提交版本及启动时间
 func State() (
 	appInfo kratos.AppInfo,
 	registrar registry.Registrar,
@@ -157,20 +363,34 @@ func State() (
 	return runtime.appInfo, runtime.registrar, runtime.build, runtime.commit, runtime.uptime
 }
 
-// Preload 指定在主程序启动时执行的函数，此方法要在 init 函数中调用，否则会被忽略
-func Preload(f func() error) {
-	runtime.preload(f)
+// Preload 指定在主程序启动时执行的函数，按登记顺序依次执行，运行时启动后调用返回 ErrStarted
+func Preload(f func() error) error {
+	return runtime.Preload(f)
+}
+
+// Defer 指定在主程序退出时执行的函数，按登记的逆序执行，运行时退出后调用返回 ErrDisposed
+func Defer(f func()) error {
+	return runtime.Defer(f)
 }
 
-// Defer 指定在主程序退出时执行的函数
-func Defer(f func()) {
-	runtime.deferIt(f)
+// Routines 返回所有伴生协程的状态快照，包括名称、状态、启动时间、最近的错误、重启次数及 panic 堆栈
+func Routines() []RoutineStatus {
+	return runtime.Routines()
 }
 
+// Current 返回默认运行时
 func Current() (current *Runtime) {
 	return runtime
 }
 
+// Replace 以 r 替换默认运行时，返回被替换的运行时，包级函数随后均作用于 r，
+// 仅用于测试，须在使用包级函数前调用，并在测试结束时恢复
+func Replace(r *Runtime) (previous *Runtime) {
+	previous, runtime = runtime, r
+	return
+}
+
+// Dispose 结束运行时上下文并等待伴生协程退出，然后在 DefaultShutdownTimeout 期限内执行所有延迟函数，错误仅记录日志
 func Dispose() {
-	runtime.dispose()
+	runtime.Dispose()
 }
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/go-kratos/kratos/v2"
 	"github.com/keepitlight/kratos/runtime"
 )
 
@@ -31,3 +32,51 @@ func ExampleStart() {
 	// output:
 	// <nil> <nil> build commit
 }
+
+func ExampleNew() {
+	r := runtime.New()
+	_ = r.PreloadWith("config", func(ctx context.Context) error {
+		fmt.Println("config loaded")
+		return nil
+	})
+	r.Defer(func() {
+		fmt.Println("disposed")
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err, ok := r.Start(ctx, nil, nil, "build", "commit", time.Now())
+	fmt.Println(err, ok)
+	r.Dispose()
+	// Output:
+	// config loaded
+	// <nil> true
+	// disposed
+}
+
+func ExampleRuntime_Lifecycle() {
+	r := runtime.New()
+	defer r.Dispose() // 服务启动失败时 kratos 不执行 AfterStop，正常退出时重复调用是安全的
+	_ = r.Preload(func() error {
+		fmt.Println("preloaded")
+		return nil
+	})
+	_ = r.Defer(func() {
+		fmt.Println("disposed")
+	})
+
+	var app *kratos.App
+	r.Co(func(ctx context.Context) error {
+		info, _ := kratos.FromContext(ctx)
+		fmt.Println("routine of", info.Name())
+		return app.Stop()
+	})
+	app = kratos.New(append(r.Lifecycle(nil, "build", "commit"), kratos.Name("example"))...)
+	if err := app.Run(); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// preloaded
+	// routine of example
+	// disposed
+}
@@ -0,0 +1,104 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+)
+
+func TestLateRegistration(t *testing.T) {
+	r := New()
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err, ok := r.Start(ctx, nil, nil, "", "", time.Now())
+	if err != nil || !ok {
+		t.Fatalf("start: %v %v", err, ok)
+	}
+
+	if err := r.Preload(func() error { return nil }); !errors.Is(err, ErrStarted) {
+		t.Errorf("late preload: %v", err)
+	}
+	if err := r.PreloadWith("late", func(context.Context) error { return nil }); !errors.Is(err, ErrStarted) {
+		t.Errorf("late named preload: %v", err)
+	}
+
+	failure := errors.New("failure")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			r.Co(func(ctx context.Context) error { return failure })
+		}()
+		go func() {
+			defer wg.Done()
+			_ = r.Defer(func() {})
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		if e := <-c; !errors.Is(e, failure) {
+			t.Errorf("unexpected error %v", e)
+		}
+	}
+	wg.Wait()
+	cancel()
+	for e := range c {
+		t.Errorf("unexpected error %v", e)
+	}
+
+	if n := len(r.Routines()); n != 10 {
+		t.Errorf("routines = %d, want 10", n)
+	}
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Defer(func() {}); !errors.Is(err, ErrDisposed) {
+		t.Errorf("defer after dispose: %v", err)
+	}
+}
+
+func TestBuildInfo(t *testing.T) {
+	defer func(f func() (*debug.BuildInfo, bool)) { readBuildInfo = f }(readBuildInfo)
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			GoVersion: "go1.24.0",
+			Main:      debug.Module{Path: "example.com/app", Version: "(devel)"},
+			Deps:      []*debug.Module{{Path: "example.com/dep", Version: "v1.0.0"}},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "0123abcd"},
+				{Key: "vcs.time", Value: "2025-01-02T03:04:05Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+
+	r := New()
+	if _, err, _ := r.Start(context.Background(), nil, nil, "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	build, commit, _ := r.State()
+	if build != "2025-01-02T03:04:05Z" || commit != "0123abcd" {
+		t.Errorf("State() = %q, %q", build, commit)
+	}
+	if b := r.Build(); !b.Modified || b.Path != "example.com/app" || len(b.Deps) != 1 {
+		t.Errorf("Build() = %+v", b)
+	}
+}
+
+// captured 记录日志内容的日志记录器
+type captured struct {
+	mu   sync.Mutex
+	logs []string
+}
+
+func (c *captured) Log(level log.Level, kv ...any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logs = append(c.logs, fmt.Sprint(kv...))
+	return nil
+}
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-kratos/kratos/v2"
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+// Lifecycle 返回将运行时接入 kratos 主程序生命周期的选项：
+//
+//   - BeforeStart 在服务监听前执行预加载步骤，主程序信息从上下文中自动获取，失败时执行延迟函数，主程序不再启动
+//   - AfterStart 在主程序启动后以主程序的上下文运行伴生协程，伴生协程的错误转发到日志
+//   - BeforeStop 在服务停止前从注册中心注销服务实例并等待传播时长
+//   - AfterStop 在服务停止后排空伴生协程并执行延迟函数
+//
+// 参数 registrar 应与主程序使用的注册中心一致，build、commit 为构建时间及提交版本，
+// 使用 Lifecycle 时不要再调用 Start，但须在 main 中 defer Dispose：
+// 服务启动失败时 kratos 不执行 AfterStop，Dispose 仅执行一次，正常退出时重复调用是安全的
+func (r *Runtime) Lifecycle(registrar registry.Registrar, build, commit string) []kratos.Option {
+	var prepared bool
+	return []kratos.Option{
+		kratos.BeforeStart(func(ctx context.Context) (err error) {
+			appInfo, _ := kratos.FromContext(ctx)
+			r.once.Do(func() {
+				err = r.prepare(ctx, appInfo, registrar, build, commit, time.Now())
+				prepared = err == nil
+			})
+			if err != nil {
+				r.Dispose() // kratos 不执行 AfterStop，在此执行延迟函数
+			} else if !prepared {
+				err = ErrStarted
+			}
+			return
+		}),
+		kratos.AfterStart(func(ctx context.Context) error {
+			go r.forward(r.run(ctx))
+			return nil
+		}),
+		kratos.BeforeStop(func(ctx context.Context) error {
+			return r.Deregister(ctx)
+		}),
+		kratos.AfterStop(func(context.Context) error {
+			ctx, cancel := context.WithTimeout(context.Background(), r.timeout())
+			defer cancel()
+			return r.DisposeContext(ctx)
+		}),
+	}
+}
+
+// forward 将伴生协程的错误转发到日志
+func (r *Runtime) forward(c <-chan error) {
+	logger := r.log()
+	for err := range c {
+		logger.Errorf("%v", err)
+	}
+}
+
+// Lifecycle 返回将默认运行时接入 kratos 主程序生命周期的选项
+func Lifecycle(registrar registry.Registrar, build, commit string) []kratos.Option {
+	return runtime.Lifecycle(registrar, build, commit)
+}
@@ -0,0 +1,26 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-kratos/kratos/v2"
+)
+
+func TestLifecyclePreloadFailure(t *testing.T) {
+	failure := errors.New("failure")
+	r := New()
+	_ = r.PreloadWith("db", func(context.Context) error { return failure })
+	var disposed int
+	_ = r.Defer(func() { disposed++ })
+
+	app := kratos.New(append(r.Lifecycle(nil, "", ""), kratos.Name("svc"))...)
+	if err := app.Run(); !errors.Is(err, failure) {
+		t.Fatalf("run = %v, want preload failure", err)
+	}
+	r.Dispose() // 重复调用不再执行延迟函数
+	if disposed != 1 {
+		t.Errorf("defers ran %d times, want 1", disposed)
+	}
+}
@@ -0,0 +1,184 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// DefaultLeaseTTL 单例伴生协程租约的默认有效期，每隔有效期的三分之一续约一次
+	DefaultLeaseTTL = 15 * time.Second
+
+	ErrNotHeld = errors.New("[kratos/runtime]lease not held")
+)
+
+// Locker 单例伴生协程使用的租约（锁）后端，实现须保证同一 key 同时仅有一个持有者
+type Locker interface {
+	// Acquire 尝试获取 key 的租约，owner 标识持有者，ttl 为租约有效期，已被其它持有者占用时返回 false
+	Acquire(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Renew 为持有的租约续约，租约已丢失时返回 false
+	Renew(ctx context.Context, key, owner string, ttl time.Duration) (bool, error)
+	// Release 释放持有的租约
+	Release(ctx context.Context, key, owner string) error
+}
+
+type lease struct {
+	owner   string
+	expires time.Time
+}
+
+// MemoryLocker 进程内的租约实现，用于测试或单实例部署
+type MemoryLocker struct {
+	clock Clock
+
+	mu     sync.Mutex
+	leases map[string]lease
+}
+
+// LockerOption 进程内租约实现的选项
+type LockerOption func(*MemoryLocker)
+
+// LockerClock 指定租约到期判断使用的时钟，应与运行时的时钟一致（参见 WithClock），未指定时使用系统时钟
+func LockerClock(clock Clock) LockerOption {
+	return func(l *MemoryLocker) {
+		if clock != nil {
+			l.clock = clock
+		}
+	}
+}
+
+// NewMemoryLocker 创建进程内的租约实现
+func NewMemoryLocker(opts ...LockerOption) *MemoryLocker {
+	l := &MemoryLocker{clock: realClock{}, leases: make(map[string]lease)}
+	for _, o := range opts {
+		o(l)
+	}
+	return l
+}
+
+func (l *MemoryLocker) now() time.Time {
+	if l.clock == nil {
+		return time.Now()
+	}
+	return l.clock.Now()
+}
+
+func (l *MemoryLocker) Acquire(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if v, ok := l.leases[key]; ok && v.owner != owner && now.Before(v.expires) {
+		return false, nil
+	}
+	l.leases[key] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (l *MemoryLocker) Renew(_ context.Context, key, owner string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	if v, ok := l.leases[key]; !ok || v.owner != owner || !now.Before(v.expires) {
+		return false, nil
+	}
+	l.leases[key] = lease{owner: owner, expires: now.Add(ttl)}
+	return true, nil
+}
+
+func (l *MemoryLocker) Release(_ context.Context, key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if v, ok := l.leases[key]; !ok || v.owner != owner {
+		return ErrNotHeld
+	}
+	delete(l.leases, key)
+	return nil
+}
+
+// singleton 单例伴生协程的租约配置
+type singleton struct {
+	locker Locker
+	key    string
+	owner  string
+	ttl    time.Duration
+}
+
+// Singleton 指定伴生协程为单例，仅在持有 locker 中 key 的租约时运行，租约自动续约，
+// 失去租约时取消伴生协程的上下文并重新竞争租约，ttl 小于等于 0 时使用 DefaultLeaseTTL
+func Singleton(locker Locker, key string, ttl time.Duration) RoutineOption {
+	return func(t *task) {
+		if locker == nil {
+			t.singleton = nil
+			return
+		}
+		if ttl <= 0 {
+			ttl = DefaultLeaseTTL
+		}
+		t.singleton = &singleton{locker: locker, key: key, owner: uuid.NewString(), ttl: ttl}
+	}
+}
+
+// lead 竞争租约，仅在持有租约期间运行伴生协程，失去租约时取消伴生协程的上下文并重新竞争，
+// 伴生协程在持有租约期间自行退出时释放租约并返回其结果
+func (t *task) lead(ctx context.Context) error {
+	s := t.singleton
+	interval := s.ttl / 3
+	for {
+		t.status.update(func(v *RoutineStatus) { v.State = StateStandby })
+		var failing bool // 租约后端持续出错，仅在首次出错时记录
+		for {
+			ok, err := s.locker.Acquire(ctx, s.key, s.owner, s.ttl)
+			if err == nil && ok {
+				break
+			}
+			if err != nil && ctx.Err() == nil && !failing {
+				e := t.wrap(fmt.Errorf("[kratos/runtime]acquire lease %s: %w", s.key, err), 0)
+				t.status.update(func(v *RoutineStatus) { v.LastError = e })
+				if t.warn != nil {
+					t.warn("%v", e)
+				}
+			}
+			failing = err != nil
+			if !sleep(ctx, t.clock, interval) {
+				return nil // 未曾运行，上下文结束时正常退出
+			}
+		}
+		t.status.update(func(v *RoutineStatus) { v.State = StateRunning })
+
+		if err, lost := t.hold(ctx, interval); !lost {
+			_ = s.locker.Release(context.WithoutCancel(ctx), s.key, s.owner)
+			return err
+		}
+	}
+}
+
+// hold 在持有租约期间运行伴生协程并定期续约，lost 表示租约已丢失
+func (t *task) hold(ctx context.Context, interval time.Duration) (err error, lost bool) {
+	s := t.singleton
+	lctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- t.call(lctx)
+	}()
+
+	for {
+		timer := t.clock.NewTimer(interval)
+		select {
+		case err = <-done:
+			timer.Stop()
+			return err, false
+		case <-timer.C():
+			if ok, e := s.locker.Renew(ctx, s.key, s.owner, s.ttl); e != nil || !ok {
+				cancel()
+				<-done
+				return nil, true
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !unix
+
+package runtime
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// FileLocker 基于文件锁的租约实现，当前平台不支持，所有操作返回 errors.ErrUnsupported
+type FileLocker struct {
+	dir string
+}
+
+// NewFileLocker 创建基于文件锁的租约实现，当前平台不支持
+func NewFileLocker(dir string) *FileLocker {
+	return &FileLocker{dir: dir}
+}
+
+func (l *FileLocker) Acquire(context.Context, string, string, time.Duration) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func (l *FileLocker) Renew(context.Context, string, string, time.Duration) (bool, error) {
+	return false, errors.ErrUnsupported
+}
+
+func (l *FileLocker) Release(context.Context, string, string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,193 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleton(t *testing.T) {
+	locker := NewMemoryLocker()
+	var running atomic.Int32
+	var leaders [2]atomic.Bool
+	replicas := make([]*Runtime, 2)
+	cancels := make([]context.CancelFunc, 2)
+	for i := range replicas {
+		replicas[i] = New()
+		replicas[i].CoWith(func(ctx context.Context) error {
+			if running.Add(1) > 1 {
+				t.Error("more than one leader")
+			}
+			leaders[i].Store(true)
+			<-ctx.Done()
+			running.Add(-1)
+			return nil
+		}, Name("scheduler"), Singleton(locker, "scheduler", 30*time.Millisecond))
+		var ctx context.Context
+		ctx, cancels[i] = context.WithCancel(context.Background())
+		replicas[i].Start(ctx, nil, nil, "", "", time.Now())
+	}
+
+	waitFor := func(f func() bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for !f() {
+			if time.Now().After(deadline) {
+				t.Fatal("timeout")
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	waitFor(func() bool { return leaders[0].Load() || leaders[1].Load() })
+	leader := 0
+	if leaders[1].Load() {
+		leader = 1
+	}
+	follower := 1 - leader
+	waitFor(func() bool { return replicas[follower].Routines()[0].State == StateStandby })
+
+	// 领导者退出后，其它副本接管
+	cancels[leader]()
+	waitFor(func() bool { return leaders[follower].Load() })
+	cancels[follower]()
+}
+
+func TestLeaseLost(t *testing.T) {
+	locker := NewMemoryLocker()
+	lost := make(chan struct{})
+	r := New()
+	r.CoWith(func(ctx context.Context) error {
+		// 模拟其它实例抢占租约
+		locker.mu.Lock()
+		locker.leases["relay"] = lease{owner: "other", expires: time.Now().Add(time.Hour)}
+		locker.mu.Unlock()
+		<-ctx.Done()
+		close(lost)
+		return nil
+	}, Singleton(locker, "relay", 30*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Start(ctx, nil, nil, "", "", time.Now())
+
+	select {
+	case <-lost:
+	case <-time.After(time.Second):
+		t.Fatal("routine context was not cancelled after losing the lease")
+	}
+}
+
+func TestFileLocker(t *testing.T) {
+	dir := t.TempDir()
+	a, b := NewFileLocker(dir), NewFileLocker(dir)
+	ctx := context.Background()
+	if ok, err := a.Acquire(ctx, "job", "a", time.Second); err != nil {
+		t.Skip(err)
+	} else if !ok {
+		t.Fatal("first acquire failed")
+	}
+	if ok, _ := b.Acquire(ctx, "job", "b", time.Second); ok {
+		t.Fatal("lock acquired twice")
+	}
+	if ok, _ := a.Renew(ctx, "job", "a", time.Second); !ok {
+		t.Error("renew failed")
+	}
+	if err := a.Release(ctx, "job", "a"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := b.Acquire(ctx, "job", "b", time.Second); !ok {
+		t.Error("acquire after release failed")
+	}
+}
+
+func TestFileLockerKeys(t *testing.T) {
+	dir := t.TempDir()
+	a, b := NewFileLocker(dir), NewFileLocker(dir)
+	ctx := context.Background()
+	// 不同 key 的最后一段路径相同时不应共用锁文件
+	if ok, err := a.Acquire(ctx, "orders/job", "a", time.Second); err != nil {
+		t.Skip(err)
+	} else if !ok {
+		t.Fatal("first acquire failed")
+	}
+	for _, key := range []string{"users/job", "job", "../job"} {
+		if ok, err := b.Acquire(ctx, key, "b", time.Second); err != nil || !ok {
+			t.Errorf("acquire %q = %v, %v; want true", key, ok, err)
+		}
+	}
+}
+
+// manualClock 手动推进的时钟
+type manualClock struct {
+	realClock
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestMemoryLockerClock(t *testing.T) {
+	clock := &manualClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	locker := NewMemoryLocker(LockerClock(clock))
+	ctx := context.Background()
+	if ok, _ := locker.Acquire(ctx, "job", "a", time.Minute); !ok {
+		t.Fatal("first acquire failed")
+	}
+	// 租约按注入的时钟计算，系统时间流逝不影响
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); ok {
+		t.Fatal("lease taken before expiry")
+	}
+	clock.Advance(time.Minute)
+	if ok, _ := locker.Renew(ctx, "job", "a", time.Minute); ok {
+		t.Error("renewed an expired lease")
+	}
+	if ok, _ := locker.Acquire(ctx, "job", "b", time.Minute); !ok {
+		t.Error("acquire after expiry failed")
+	}
+}
+
+// brokenLocker 始终出错的租约后端
+type brokenLocker struct{ MemoryLocker }
+
+func (*brokenLocker) Acquire(context.Context, string, string, time.Duration) (bool, error) {
+	return false, errors.New("backend down")
+}
+
+func TestLeaseBackendError(t *testing.T) {
+	logger := &captured{}
+	r := New(WithLogger(logger))
+	r.CoWith(func(ctx context.Context) error { return nil }, Singleton(&brokenLocker{}, "relay", 3*time.Millisecond))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.Start(ctx, nil, nil, "", "", time.Now())
+
+	deadline := time.Now().Add(time.Second)
+	for r.Routines()[0].LastError == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("backend error not recorded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if s := r.Routines()[0]; s.State != StateStandby || !strings.Contains(s.LastError.Error(), "backend down") {
+		t.Errorf("routine = %v %v, want standby with backend error", s.State, s.LastError)
+	}
+	time.Sleep(20 * time.Millisecond) // 持续出错仅记录一次
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if n := len(logger.logs); n != 1 {
+		t.Errorf("logged %d times, want 1: %v", n, logger.logs)
+	}
+}
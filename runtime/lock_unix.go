@@ -0,0 +1,80 @@
+//go:build unix
+
+package runtime
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileLocker 基于文件锁（flock）的租约实现，用于单主机多进程部署，
+// 每个 key 对应目录下的一个锁文件，租约在进程退出时由操作系统自动释放，无需续约
+type FileLocker struct {
+	dir string
+
+	mu    sync.Mutex
+	files map[string]*os.File // key → 持有锁的文件
+}
+
+// NewFileLocker 创建基于文件锁的租约实现，锁文件存放在目录 dir 下
+func NewFileLocker(dir string) *FileLocker {
+	return &FileLocker{dir: dir, files: make(map[string]*os.File)}
+}
+
+// path 返回 key 对应的锁文件路径，文件名取整个 key 的哈希，避免不同 key 映射到同一文件
+func (l *FileLocker) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(l.dir, hex.EncodeToString(sum[:])+".lock")
+}
+
+func (l *FileLocker) Acquire(_ context.Context, key, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, ok := l.files[key+"\x00"+owner]; ok {
+		return true, nil
+	}
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return false, err
+	}
+	f, err := os.OpenFile(l.path(key), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return false, err
+	}
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return false, nil
+		}
+		return false, err
+	}
+	_ = f.Truncate(0)
+	_, _ = f.WriteAt([]byte(owner), 0)
+	l.files[key+"\x00"+owner] = f
+	return true, nil
+}
+
+func (l *FileLocker) Renew(_ context.Context, key, owner string, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, ok := l.files[key+"\x00"+owner]
+	return ok, nil
+}
+
+func (l *FileLocker) Release(_ context.Context, key, owner string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.files[key+"\x00"+owner]
+	if !ok {
+		return ErrNotHeld
+	}
+	delete(l.files, key+"\x00"+owner)
+	_ = syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	return f.Close()
+}
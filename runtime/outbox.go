@@ -0,0 +1,84 @@
+package runtime
+
+import "sync"
+
+// outboxSize 消息通道发送队列的容量，无人读取消息通道时超出容量的消息被丢弃
+const outboxSize = 1024
+
+// outbox 消息通道的发送队列，发送方不等待接收方，消息按顺序投递，
+// 开始排空后丢弃未投递的消息，封存且投递完毕后关闭消息通道
+type outbox struct {
+	c        chan error
+	mu       sync.Mutex
+	queue    []error
+	notify   chan struct{} // 有新消息
+	sealed   chan struct{} // 不再有新消息
+	draining chan struct{} // 开始排空，不再等待接收方
+	drain    sync.Once
+}
+
+func newOutbox() *outbox {
+	o := &outbox{
+		c:        make(chan error),
+		notify:   make(chan struct{}, 1),
+		sealed:   make(chan struct{}),
+		draining: make(chan struct{}),
+	}
+	go o.deliver()
+	return o
+}
+
+// send 将消息加入发送队列，队列已满时丢弃消息并返回 false
+func (o *outbox) send(err error) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.queue) >= outboxSize {
+		return false
+	}
+	o.queue = append(o.queue, err)
+	select {
+	case o.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// deliver 按顺序投递消息，封存且投递完毕后关闭消息通道
+func (o *outbox) deliver() {
+	defer close(o.c)
+	for {
+		o.mu.Lock()
+		queue := o.queue
+		o.queue = nil
+		o.mu.Unlock()
+		for _, err := range queue {
+			select {
+			case o.c <- err:
+			case <-o.draining: // 排空不依赖调用方读取消息通道
+			}
+		}
+		if len(queue) > 0 {
+			continue
+		}
+		select {
+		case <-o.notify:
+		case <-o.sealed:
+			o.mu.Lock()
+			empty := len(o.queue) == 0
+			o.mu.Unlock()
+			if empty {
+				return
+			}
+		}
+	}
+}
+
+// seal 所有伴生协程退出后调用，投递完毕后关闭消息通道
+func (o *outbox) seal() {
+	close(o.sealed)
+}
+
+// discard 开始排空，丢弃未投递的消息
+func (o *outbox) discard() {
+	o.drain.Do(func() { close(o.draining) })
+}
@@ -0,0 +1,204 @@
+// Package overlay 提供按场景叠加的配置源，在基础配置文件之上叠加场景配置文件，
+// 例如生产场景下 config.yaml 之上叠加 config.rel.yaml，实现 kratos 的 config.Source，
+// 叠加后的配置按原有方式通过 config.Scan 读取
+package overlay
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/encoding"
+	"github.com/keepitlight/kratos/runtime"
+)
+
+var (
+	// DefaultWatchInterval 检查配置文件变化的默认间隔
+	DefaultWatchInterval = 5 * time.Second
+
+	// placeholder 环境变量占位符，${NAME} 或 ${NAME:default}
+	placeholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::[^}]*)?}`)
+)
+
+// Option 配置源选项
+type Option func(s *source)
+
+// Scene 指定叠加的场景，未指定时使用默认运行时的场景
+func Scene(scene runtime.SceneType) Option {
+	return func(s *source) {
+		s.scene = scene
+	}
+}
+
+// Interpolate 指定是否以环境变量替换配置文件中的 ${NAME} 及 ${NAME:default} 占位符，默认替换，
+// 仅替换 JSON 及 YAML 配置解码后的字符串值，环境变量的值不会破坏配置文件的语法，
+// 未设置的环境变量保留占位符，由 kratos 配置的解析器按配置键或默认值解析
+func Interpolate(enabled bool) Option {
+	return func(s *source) {
+		s.interpolate = enabled
+	}
+}
+
+// WatchInterval 指定检查配置文件变化的间隔，未指定时使用 DefaultWatchInterval
+func WatchInterval(d time.Duration) Option {
+	return func(s *source) {
+		s.interval = d
+	}
+}
+
+type source struct {
+	path        string
+	scene       runtime.SceneType
+	interpolate bool
+	interval    time.Duration
+}
+
+// NewSource 创建按场景叠加的配置源，path 为基础配置文件，场景配置文件与基础配置文件位于同一目录，
+// 名称为基础配置文件名加场景名称，例如 config.yaml 的生产场景配置文件为 config.rel.yaml，
+// 场景配置文件不存在时仅使用基础配置文件，配置格式由文件扩展名决定
+func NewSource(path string, options ...Option) config.Source {
+	s := &source{path: path, scene: runtime.CurrentScene(), interpolate: true, interval: DefaultWatchInterval}
+	for _, option := range options {
+		if option != nil {
+			option(s)
+		}
+	}
+	if s.interval <= 0 {
+		s.interval = DefaultWatchInterval
+	}
+	return s
+}
+
+// files 返回基础配置文件及场景配置文件的路径
+func (s *source) files() []string {
+	ext := filepath.Ext(s.path)
+	return []string{s.path, strings.TrimSuffix(s.path, ext) + "." + s.scene.String() + ext}
+}
+
+// Load 依次加载基础配置文件及场景配置文件，后者覆盖前者的同名配置
+func (s *source) Load() ([]*config.KeyValue, error) {
+	var kvs []*config.KeyValue
+	for i, file := range s.files() {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if i > 0 && errors.Is(err, fs.ErrNotExist) {
+				continue // 场景配置文件是可选的
+			}
+			return nil, err
+		}
+		format := strings.TrimPrefix(filepath.Ext(file), ".")
+		if s.interpolate {
+			if data, err = interpolate(data, format); err != nil {
+				return nil, fmt.Errorf("[kratos/runtime]interpolate %s: %w", file, err)
+			}
+		}
+		kvs = append(kvs, &config.KeyValue{
+			Key:    filepath.Base(file),
+			Value:  data,
+			Format: format,
+		})
+	}
+	return kvs, nil
+}
+
+// interpolate 解码 JSON 及 YAML 配置，替换字符串值中的占位符后按原格式编码，其它格式原样返回
+func interpolate(data []byte, format string) ([]byte, error) {
+	codec := encoding.GetCodec(format)
+	if codec == nil || (format != "json" && format != "yaml") || len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+	if !placeholder.Match(data) {
+		return data, nil // 无占位符，保留原文
+	}
+	var v map[string]any
+	if err := codec.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	return codec.Marshal(expand(v))
+}
+
+// expand 以已设置的环境变量替换解码后的配置中字符串值的占位符
+func expand(v any) any {
+	switch x := v.(type) {
+	case string:
+		return placeholder.ReplaceAllStringFunc(x, func(m string) string {
+			if v, ok := os.LookupEnv(placeholder.FindStringSubmatch(m)[1]); ok {
+				return v
+			}
+			return m
+		})
+	case map[string]any:
+		for k, e := range x {
+			x[k] = expand(e)
+		}
+	case []any:
+		for i, e := range x {
+			x[i] = expand(e)
+		}
+	}
+	return v
+}
+
+// Watch 定期检查配置文件的修改时间及大小，变化且保持一个检查间隔不变后重新加载，避免读取写入中的文件
+func (s *source) Watch() (config.Watcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &watcher{source: s, ctx: ctx, cancel: cancel, last: s.stamp()}, nil
+}
+
+// stamp 返回配置文件的修改时间及大小，用于判断是否变化
+func (s *source) stamp() []byte {
+	var b bytes.Buffer
+	for _, file := range s.files() {
+		if info, err := os.Stat(file); err == nil {
+			fmt.Fprintf(&b, "%d:%d;", info.ModTime().UnixNano(), info.Size())
+		} else {
+			b.WriteString("-;")
+		}
+	}
+	return b.Bytes()
+}
+
+type watcher struct {
+	source *source
+	ctx    context.Context
+	cancel context.CancelFunc
+	last   []byte
+	change []byte // 已观察到但尚未稳定的变化
+}
+
+// Next 阻塞直到配置文件变化，返回重新加载的配置，停止后返回 context.Canceled
+func (w *watcher) Next() ([]*config.KeyValue, error) {
+	ticker := time.NewTicker(w.source.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.ctx.Done():
+			return nil, w.ctx.Err()
+		case <-ticker.C:
+			stamp := w.source.stamp()
+			if bytes.Equal(stamp, w.last) {
+				w.change = nil
+				continue
+			}
+			if !bytes.Equal(stamp, w.change) {
+				w.change = stamp // 文件可能正在写入，等待下一次检查确认
+				continue
+			}
+			w.last, w.change = stamp, nil
+			return w.source.Load()
+		}
+	}
+}
+
+func (w *watcher) Stop() error {
+	w.cancel()
+	return nil
+}
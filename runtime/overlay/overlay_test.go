@@ -0,0 +1,110 @@
+package overlay
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/keepitlight/kratos/runtime"
+)
+
+func TestSource(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		// 先写入临时文件再重命名，避免读取到写入中的文件
+		tmp := filepath.Join(dir, name+".tmp")
+		if err := os.WriteFile(tmp, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config.yaml", "server:\n  addr: ${ADDR:0.0.0.0:8000}\n  timeout: 1s\ndata:\n  dsn: ${DSN}\n")
+	write("config.rel.yaml", "server:\n  timeout: 3s\n")
+	t.Setenv("DSN", "mysql://prod")
+
+	var v struct {
+		Server struct {
+			Addr    string `json:"addr"`
+			Timeout string `json:"timeout"`
+		} `json:"server"`
+		Data struct {
+			DSN string `json:"dsn"`
+		} `json:"data"`
+	}
+	c := config.New(config.WithSource(NewSource(filepath.Join(dir, "config.yaml"),
+		Scene(runtime.SceneRel), WatchInterval(10*time.Millisecond))))
+	defer c.Close()
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Scan(&v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Server.Addr != "0.0.0.0:8000" || v.Server.Timeout != "3s" || v.Data.DSN != "mysql://prod" {
+		t.Errorf("unexpected config: %+v", v)
+	}
+
+	changed := make(chan string, 8)
+	if err := c.Watch("server.timeout", func(_ string, value config.Value) {
+		s, _ := value.String()
+		changed <- s
+	}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	write("config.rel.yaml", "server:\n  timeout: 5s\n")
+	deadline := time.After(time.Second)
+	for s := ""; s != "5s"; {
+		select {
+		case s = <-changed:
+		case <-deadline:
+			t.Fatalf("timeout after change = %q, want 5s", s)
+		}
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"config.yaml": "data:\n  dsn: ${DSN}\n  motd: ${MOTD}\n  quote: \"say ${QUOTE}\"\n  name: ${NAME}\n",
+		"config.json": `{"data": {"dsn": "${DSN}", "motd": "${MOTD}", "quote": "say ${QUOTE}", "name": "${NAME}"}}`,
+	}
+	t.Setenv("DSN", "user:pass@tcp(db: 3306)/app # primary")
+	t.Setenv("MOTD", "line1\nline2")
+	t.Setenv("QUOTE", `"hi" 'there'`)
+	t.Setenv("NAME", "svc\nadmin: true") // 不应注入额外的配置键
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c := config.New(config.WithSource(NewSource(path, Scene(runtime.SceneDev))))
+		if err := c.Load(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		var v struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := c.Scan(&v); err != nil {
+			t.Fatal(err)
+		}
+		want := map[string]string{
+			"dsn":   "user:pass@tcp(db: 3306)/app # primary",
+			"motd":  "line1\nline2",
+			"quote": `say "hi" 'there'`,
+			"name":  "svc\nadmin: true",
+		}
+		if !maps.Equal(v.Data, want) {
+			t.Errorf("%s: data = %q, want %q", name, v.Data, want)
+		}
+		if _, err := c.Value("admin").Bool(); err == nil {
+			t.Errorf("%s: injected key admin", name)
+		}
+		c.Close()
+	}
+}
@@ -0,0 +1,118 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FailurePolicy 伴生协程失败时运行时的处理策略
+type FailurePolicy int
+
+const (
+	FailIgnore FailurePolicy = iota // 忽略，伴生协程失败不影响主程序，默认策略
+	FailFast                        // 关键伴生协程失败时结束运行时上下文并关闭主程序
+)
+
+var (
+	ErrFailFast = errors.New("[kratos/runtime]critical routine failed, shutting down")
+	ErrQuorum   = errors.New("[kratos/runtime]routine group below quorum, shutting down")
+)
+
+// WithFailurePolicy 指定伴生协程失败时运行时的处理策略，未指定时为 FailIgnore
+func WithFailurePolicy(policy FailurePolicy) Option {
+	return func(r *Runtime) {
+		r.policy = policy
+	}
+}
+
+// WithQuorum 指定伴生协程组的法定数量，组内健康（未失败且未退出）的伴生协程少于 n 个时，
+// 结束运行时上下文并关闭主程序，伴生协程通过 Group 选项加入组
+func WithQuorum(group string, n int) Option {
+	return func(r *Runtime) {
+		if r.quorums == nil {
+			r.quorums = make(map[string]int)
+		}
+		r.quorums[group] = n
+	}
+}
+
+// Group 指定伴生协程所属的组，用于法定数量检查
+func Group(name string) RoutineOption {
+	return func(t *task) {
+		t.status.Group = name
+	}
+}
+
+// settle 伴生协程在运行时上下文结束前退出时，按失败策略及法定数量决定是否关闭运行时
+func (r *Runtime) settle(ctx context.Context, t *task, send func(err error)) {
+	if ctx.Err() != nil {
+		return
+	}
+	s := t.status.snapshot()
+
+	r.mu.Lock()
+	policy, quorum := r.policy, r.quorums[s.Group]
+	r.mu.Unlock()
+
+	var cause error
+	switch {
+	case policy == FailFast && s.Critical && s.State == StateFailed:
+		cause = fmt.Errorf("%w: routine %s", ErrFailFast, s.Name)
+		if s.LastError != nil {
+			cause = fmt.Errorf("%w: %w", ErrFailFast, s.LastError)
+		}
+	case s.Group != "" && quorum > 0:
+		if healthy := r.healthy(s.Group); healthy < quorum {
+			cause = fmt.Errorf("%w: group %s has %d of %d healthy routines", ErrQuorum, s.Group, healthy, quorum)
+		}
+	}
+	if cause != nil {
+		r.shutdown(cause, send)
+	}
+}
+
+// healthy 返回组内未失败且未退出的伴生协程数量
+func (r *Runtime) healthy(group string) (n int) {
+	for _, s := range r.Routines() {
+		if s.Group == group && s.State != StateFailed && s.State != StateStopped {
+			n++
+		}
+	}
+	return
+}
+
+// shutdown 结束运行时上下文，主程序支持 Stop 时（kratos.App）一并关闭主程序，随后通过 send 发送关闭原因，
+// 关闭不依赖调用方读取消息通道，仅执行一次
+func (r *Runtime) shutdown(cause error, send func(err error)) {
+	r.mu.Lock()
+	if r.halted {
+		r.mu.Unlock()
+		return
+	}
+	r.halted = true
+	cancel, app := r.cancel, r.appInfo
+	r.mu.Unlock()
+
+	r.log().Errorf("%v", cause)
+	cancel()
+	if s, ok := app.(interface{ Stop() error }); ok {
+		go func() {
+			if err := s.Stop(); err != nil {
+				r.log().Errorf("[kratos/runtime]stop app: %v", err)
+			}
+		}()
+	}
+	send(cause)
+}
+
+// Configure 为默认运行时应用选项，例如 WithFailurePolicy、WithQuorum、WithErrorHook
+func Configure(options ...Option) {
+	runtime.mu.Lock()
+	defer runtime.mu.Unlock()
+	for _, option := range options {
+		if option != nil {
+			option(runtime)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFailFast(t *testing.T) {
+	failure := errors.New("failure")
+	r := New(WithFailurePolicy(FailFast))
+	r.Co(func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	r.CoWith(func(ctx context.Context) error { return failure }, Name("consumer"), Critical())
+
+	errs := collect(context.Background(), r)
+	if len(errs) != 2 || !errors.Is(errs[1], ErrFailFast) || !errors.Is(errs[1], failure) {
+		t.Fatalf("errors = %v, want routine failure followed by ErrFailFast", errs)
+	}
+	if s := r.Routines()[0]; s.State != StateStopped {
+		t.Errorf("routine-1 state = %v, want stopped", s.State)
+	}
+}
+
+func TestFailFastUnreadChannel(t *testing.T) {
+	r := New(WithFailurePolicy(FailFast), WithDrainTimeout(time.Second))
+	r.CoWith(func(ctx context.Context) error { return errors.New("failure") }, Critical())
+	r.run(context.Background()) // 无人读取消息通道
+
+	r.mu.Lock()
+	ctx := r.ctx
+	r.mu.Unlock()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("runtime context not cancelled by fail-fast")
+	}
+	if err := r.DisposeContext(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestQuorum(t *testing.T) {
+	var calls atomic.Int32
+	r := New(WithQuorum("workers", 2))
+	for range 3 {
+		r.CoWith(func(ctx context.Context) error {
+			if calls.Add(1) == 3 {
+				<-ctx.Done()
+				return nil
+			}
+			return errors.New("failure")
+		}, Group("workers"))
+	}
+
+	errs := collect(context.Background(), r)
+	var quorum int
+	for _, e := range errs {
+		if errors.Is(e, ErrQuorum) {
+			quorum++
+		}
+	}
+	if quorum != 1 {
+		t.Errorf("errors = %v, want exactly one ErrQuorum", errs)
+	}
+}
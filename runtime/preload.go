@@ -0,0 +1,256 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	ErrPreloadName    = errors.New("[kratos/runtime]preload step name is empty")
+	ErrPreloadExists  = errors.New("[kratos/runtime]preload step already exists")
+	ErrPreloadCycle   = errors.New("[kratos/runtime]preload dependency cycle")
+	ErrPreloadMissing = errors.New("[kratos/runtime]preload dependency missing")
+
+	errSkipped = errors.New("[kratos/runtime]preload step skipped") // 因其它步骤失败而跳过，不作为错误报告
+)
+
+// PreloadError 预加载步骤失败的错误，Step 为失败的步骤名称
+type PreloadError struct {
+	Step string
+	Err  error
+}
+
+func (e *PreloadError) Error() string {
+	return fmt.Sprintf("[kratos/runtime]preload %s: %v", e.Step, e.Err)
+}
+
+func (e *PreloadError) Unwrap() error {
+	return e.Err
+}
+
+// PreloadOption 预加载步骤选项
+type PreloadOption func(s *step)
+
+// DependsOn 指定预加载步骤依赖的其它步骤，依赖的步骤全部成功后才执行，
+// 依赖的步骤可以晚于当前步骤登记，但必须在运行时启动前登记
+func DependsOn(steps ...string) PreloadOption {
+	return func(s *step) {
+		s.deps = append(s.deps, steps...)
+	}
+}
+
+// Timeout 指定预加载步骤的超时时间，超时后步骤视为失败
+func Timeout(d time.Duration) PreloadOption {
+	return func(s *step) {
+		s.timeout = d
+	}
+}
+
+// step 预加载步骤
+type step struct {
+	name      string
+	fn        func(ctx context.Context) error
+	deps      []string
+	timeout   time.Duration
+	anonymous bool // 通过 Preload 登记的匿名步骤，按登记顺序依次执行
+}
+
+// exec 在超时限制内执行步骤，即便步骤函数未响应上下文，超时后也立即返回
+func (s *step) exec(ctx context.Context) error {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+	}
+	return Await(ctx, s.fn)
+}
+
+// find 查找指定名称的预加载步骤
+func (r *Runtime) find(name string) *step {
+	for _, s := range r.readies {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// cyclic 检查从 from 出发沿依赖能否回到 from
+func (r *Runtime) cyclic(from *step) bool {
+	visited := make(map[string]bool)
+	var visit func(s *step) bool
+	visit = func(s *step) bool {
+		for _, d := range s.deps {
+			if d == from.name {
+				return true
+			}
+			if visited[d] {
+				continue
+			}
+			visited[d] = true
+			if n := r.find(d); n != nil && visit(n) {
+				return true
+			}
+		}
+		return false
+	}
+	return visit(from)
+}
+
+// anonymous 返回最后登记的匿名步骤的名称及匿名步骤的数量
+func (r *Runtime) anonymous() (name string, count int) {
+	for _, s := range slices.Backward(r.readies) {
+		if s.anonymous {
+			if name == "" {
+				name = s.name
+			}
+			count++
+		}
+	}
+	return
+}
+
+// Preload 登记匿名的预加载步骤，匿名步骤依赖前一个匿名步骤，保持登记顺序执行
+func (r *Runtime) Preload(f func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return ErrStarted
+	}
+	if f == nil {
+		return nil
+	}
+	prev, count := r.anonymous()
+	name := fmt.Sprintf("preload-%d", count+1)
+	for n := count + 2; r.find(name) != nil; n++ {
+		name = fmt.Sprintf("preload-%d", n) // 跳过已被具名步骤占用的名称
+	}
+	s := &step{
+		name:      name,
+		fn:        func(context.Context) error { return f() },
+		anonymous: true,
+	}
+	if prev != "" {
+		s.deps = []string{prev}
+	}
+	r.readies = append(r.readies, s)
+	return nil
+}
+
+// PreloadWith 登记具名的预加载步骤，名称重复、形成循环依赖或运行时已启动时返回错误
+func (r *Runtime) PreloadWith(name string, f func(ctx context.Context) error, options ...PreloadOption) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return ErrStarted
+	}
+	if name == "" {
+		return ErrPreloadName
+	}
+	if f == nil {
+		return nil
+	}
+	if r.find(name) != nil {
+		return fmt.Errorf("%w: %s", ErrPreloadExists, name)
+	}
+	s := &step{name: name, fn: f}
+	for _, option := range options {
+		if option != nil {
+			option(s)
+		}
+	}
+	if r.cyclic(s) {
+		return fmt.Errorf("%w: %s", ErrPreloadCycle, name)
+	}
+	r.readies = append(r.readies, s)
+	return nil
+}
+
+// load 按依赖关系执行所有预加载步骤，相互独立的步骤并行执行，
+// 任一步骤失败时取消其余步骤，返回包含所有失败步骤的聚合错误
+func (r *Runtime) load(ctx context.Context) error {
+	for _, s := range r.readies {
+		for _, d := range s.deps {
+			if r.find(d) == nil {
+				return &PreloadError{Step: s.name, Err: fmt.Errorf("%w: %s", ErrPreloadMissing, d)}
+			}
+		}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		done chan struct{}
+		err  error
+	}
+	results := make(map[*step]*result, len(r.readies))
+	for _, s := range r.readies {
+		results[s] = &result{done: make(chan struct{})}
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool // 已有步骤失败，其余步骤因取消而失败的错误不再报告
+	)
+	wg.Add(len(r.readies))
+	for _, s := range r.readies {
+		go func(s *step, res *result) {
+			defer wg.Done()
+			defer close(res.done)
+			for _, d := range s.deps {
+				dep := results[r.find(d)]
+				<-dep.done
+				if dep.err != nil {
+					res.err = errSkipped // 依赖失败，跳过
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				if err := parent.Err(); err != nil {
+					res.err = &PreloadError{Step: s.name, Err: err} // 上下文已结束，步骤未执行
+				} else {
+					res.err = errSkipped // 其它步骤失败，跳过
+				}
+				return
+			}
+			r.emit(Event{Type: EventPreloadStarted, Step: s.name})
+			if err := s.exec(ctx); err != nil {
+				mu.Lock()
+				if failed && errors.Is(err, context.Canceled) {
+					res.err = errSkipped
+				} else {
+					res.err = &PreloadError{Step: s.name, Err: err}
+				}
+				failed = true
+				mu.Unlock()
+				cancel()
+				r.emit(Event{Type: EventPreloadFailed, Step: s.name, Err: err})
+				return
+			}
+			r.emit(Event{Type: EventPreloadFinished, Step: s.name})
+		}(s, results[s])
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, s := range r.readies {
+		var pe *PreloadError
+		if err := results[s].err; errors.As(err, &pe) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// PreloadWith 登记具名的预加载步骤，在主程序启动时按依赖关系执行，相互独立的步骤并行执行，
+// 名称重复或形成循环依赖时返回错误，运行时启动后调用返回 ErrStarted
+func PreloadWith(name string, f func(ctx context.Context) error, options ...PreloadOption) error {
+	return runtime.PreloadWith(name, f, options...)
+}
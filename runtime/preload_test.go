@@ -0,0 +1,107 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPreloadOrder(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	r := New()
+	if err := r.PreloadWith("cache", record("cache"), DependsOn("db", "config")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PreloadWith("db", record("db"), DependsOn("config")); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.PreloadWith("config", record("config")); err != nil {
+		t.Fatal(err)
+	}
+	r.Preload(func() error { return record("first")(nil) })
+	if err := r.PreloadWith("preload-2", record("named")); err != nil { // 占用匿名步骤的名称
+		t.Fatal(err)
+	}
+	r.Preload(func() error { return record("second")(nil) })
+
+	if err := r.load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	index := func(name string) int { return slices.Index(order, name) }
+	if !(index("config") < index("db") && index("db") < index("cache") && index("first") < index("second") && index("named") >= 0) {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestPreloadRegistration(t *testing.T) {
+	r := New()
+	noop := func(context.Context) error { return nil }
+	_ = r.PreloadWith("a", noop, DependsOn("b"))
+	if err := r.PreloadWith("a", noop); !errors.Is(err, ErrPreloadExists) {
+		t.Errorf("duplicate step: %v", err)
+	}
+	if err := r.PreloadWith("b", noop, DependsOn("a")); !errors.Is(err, ErrPreloadCycle) {
+		t.Errorf("cycle: %v", err)
+	}
+	if err := r.load(context.Background()); !errors.Is(err, ErrPreloadMissing) {
+		t.Errorf("missing dependency: %v", err)
+	}
+}
+
+func TestPreloadFailure(t *testing.T) {
+	failure := errors.New("failure")
+	var ran bool
+	r := New()
+	_ = r.PreloadWith("db", func(context.Context) error { return failure })
+	_ = r.PreloadWith("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, Timeout(time.Millisecond))
+	_ = r.PreloadWith("cache", func(context.Context) error {
+		ran = true
+		return nil
+	}, DependsOn("db"))
+
+	err := r.load(context.Background())
+	var pe *PreloadError
+	if !errors.As(err, &pe) || !errors.Is(err, failure) {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if ran {
+		t.Error("dependent step ran after its dependency failed")
+	}
+}
+
+func TestPreloadCanceled(t *testing.T) {
+	var ran atomic.Bool
+	r := New()
+	_ = r.PreloadWith("config", func(context.Context) error {
+		ran.Store(true)
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err, ok := r.Start(ctx, nil, nil, "", "", time.Now())
+	var pe *PreloadError
+	if !errors.As(err, &pe) || pe.Step != "config" || !errors.Is(err, context.Canceled) || ok {
+		t.Fatalf("start = %v, %v, want PreloadError naming config", err, ok)
+	}
+	if ran.Load() || r.Ready() {
+		t.Errorf("ran = %t, ready = %t, want neither", ran.Load(), r.Ready())
+	}
+}
@@ -0,0 +1,142 @@
+package runtime
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+var (
+	// DefaultPropagationDelay 注销服务实例后等待客户端停止路由的默认时长
+	DefaultPropagationDelay time.Duration
+)
+
+// WithPropagationDelay 指定注销服务实例后、排空伴生协程前的等待时长，以便注销在注册中心及客户端间传播，
+// 未指定时使用 DefaultPropagationDelay
+func WithPropagationDelay(d time.Duration) Option {
+	return func(r *Runtime) {
+		r.propagation = d
+	}
+}
+
+// instance 返回当前服务实例，合并主程序的元数据及运行中更新的元数据，主程序信息无效时返回 nil
+func (r *Runtime) instance() *registry.ServiceInstance {
+	if r.appInfo == nil {
+		return nil
+	}
+	metadata := maps.Clone(r.appInfo.Metadata())
+	if metadata == nil {
+		metadata = make(map[string]string, len(r.metadata))
+	}
+	maps.Copy(metadata, r.metadata)
+	return &registry.ServiceInstance{
+		ID:        r.appInfo.ID(),
+		Name:      r.appInfo.Name(),
+		Version:   r.appInfo.Version(),
+		Metadata:  metadata,
+		Endpoints: slices.Clone(r.appInfo.Endpoint()),
+	}
+}
+
+// UpdateMetadata 更新服务实例的元数据，例如标记 draining 或当前的 Scene，
+// 运行时已启动且有注册中心时，通过注册中心重新注册以发布更新，注销后仅记录不再发布
+func (r *Runtime) UpdateMetadata(ctx context.Context, metadata map[string]string) error {
+	r.publish.Lock() // 与注销互斥，避免注销后重新注册
+	defer r.publish.Unlock()
+
+	r.mu.Lock()
+	if r.metadata == nil {
+		r.metadata = make(map[string]string, len(metadata))
+	}
+	maps.Copy(r.metadata, metadata)
+	if r.registrar == nil || r.deregistered {
+		r.mu.Unlock()
+		return nil
+	}
+	registrar, instance := r.registrar, r.instance()
+	r.mu.Unlock()
+	if instance == nil {
+		return nil
+	}
+	return registrar.Register(ctx, instance)
+}
+
+// Deregister 从注册中心注销服务实例，并等待传播时长，仅执行一次，
+// 无注册中心或主程序信息无效时不执行任何操作，Dispose 时自动调用
+func (r *Runtime) Deregister(ctx context.Context) error {
+	r.publish.Lock()
+	r.mu.Lock()
+	if r.deregistered || r.registrar == nil {
+		r.mu.Unlock()
+		r.publish.Unlock()
+		return nil
+	}
+	r.deregistered = true
+	registrar, instance := r.registrar, r.instance()
+	delay := r.propagation
+	r.mu.Unlock()
+	if instance == nil {
+		r.publish.Unlock()
+		return nil
+	}
+
+	err := registrar.Deregister(ctx, instance)
+	r.publish.Unlock()
+	if err != nil {
+		return err
+	}
+	if delay <= 0 {
+		delay = DefaultPropagationDelay
+	}
+	r.log().Infof("[kratos/runtime]deregistered %s, waiting %v for propagation", instance.ID, delay)
+	sleep(ctx, r.Clock(), delay)
+	return nil
+}
+
+// UpdateMetadata 更新默认运行时服务实例的元数据，并通过注册中心发布
+func UpdateMetadata(ctx context.Context, metadata map[string]string) error {
+	return runtime.UpdateMetadata(ctx, metadata)
+}
+
+// MemoryRegistrar 进程内的注册中心，用于测试，按服务名称记录已注册的服务实例
+type MemoryRegistrar struct {
+	mu        sync.Mutex
+	instances map[string][]*registry.ServiceInstance
+}
+
+// NewMemoryRegistrar 创建进程内的注册中心
+func NewMemoryRegistrar() *MemoryRegistrar {
+	return &MemoryRegistrar{instances: make(map[string][]*registry.ServiceInstance)}
+}
+
+// Register 注册服务实例，相同 ID 的实例视为更新
+func (m *MemoryRegistrar) Register(_ context.Context, instance *registry.ServiceInstance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := slices.DeleteFunc(m.instances[instance.Name], func(v *registry.ServiceInstance) bool {
+		return v.ID == instance.ID
+	})
+	m.instances[instance.Name] = append(list, instance)
+	return nil
+}
+
+// Deregister 注销服务实例
+func (m *MemoryRegistrar) Deregister(_ context.Context, instance *registry.ServiceInstance) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.instances[instance.Name] = slices.DeleteFunc(m.instances[instance.Name], func(v *registry.ServiceInstance) bool {
+		return v.ID == instance.ID
+	})
+	return nil
+}
+
+// GetService 返回指定名称的已注册服务实例
+func (m *MemoryRegistrar) GetService(_ context.Context, name string) ([]*registry.ServiceInstance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.instances[name]), nil
+}
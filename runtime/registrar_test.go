@@ -0,0 +1,85 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/registry"
+)
+
+// app 测试用的主程序信息
+type app struct{}
+
+func (app) ID() string                  { return "id-1" }
+func (app) Name() string                { return "svc" }
+func (app) Version() string             { return "v1" }
+func (app) Metadata() map[string]string { return map[string]string{"zone": "a"} }
+func (app) Endpoint() []string          { return []string{"grpc://127.0.0.1:9000"} }
+
+func TestDeregister(t *testing.T) {
+	ctx := context.Background()
+	registrar := NewMemoryRegistrar()
+	r := New(WithPropagationDelay(time.Millisecond))
+	r.DeferWith("db", func(ctx context.Context) error {
+		if list, _ := registrar.GetService(ctx, "svc"); len(list) != 0 {
+			t.Errorf("instance still registered while running defers: %v", list)
+		}
+		return nil
+	})
+	if _, err, _ := r.Start(ctx, app{}, registrar, "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.UpdateMetadata(ctx, map[string]string{"scene": "test"}); err != nil {
+		t.Fatal(err)
+	}
+	list, _ := registrar.GetService(ctx, "svc")
+	if len(list) != 1 || list[0].Metadata["scene"] != "test" || list[0].Metadata["zone"] != "a" {
+		t.Fatalf("unexpected instances: %+v", list)
+	}
+	if err := r.DisposeContext(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateMetadata(ctx, map[string]string{"state": "draining"}); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := registrar.GetService(ctx, "svc"); len(list) != 0 {
+		t.Errorf("instance registered after dispose: %+v", list)
+	}
+}
+
+// slowRegistrar 注册时阻塞直到 release 关闭
+type slowRegistrar struct {
+	*MemoryRegistrar
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *slowRegistrar) Register(ctx context.Context, instance *registry.ServiceInstance) error {
+	close(s.entered)
+	<-s.release
+	return s.MemoryRegistrar.Register(ctx, instance)
+}
+
+func TestUpdateMetadataUnlocked(t *testing.T) {
+	ctx := context.Background()
+	registrar := &slowRegistrar{NewMemoryRegistrar(), make(chan struct{}), make(chan struct{})}
+	r := New()
+	if _, err, _ := r.Start(ctx, app{}, registrar, "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Dispose()
+
+	done := make(chan error, 1)
+	go func() { done <- r.UpdateMetadata(ctx, map[string]string{"state": "draining"}) }()
+	<-registrar.entered
+	r.Co(func(ctx context.Context) error { return nil }) // 注册中心调用期间不阻塞登记及查询
+	if n := len(r.Routines()); n != 1 {
+		t.Errorf("routines = %d, want 1", n)
+	}
+	close(registrar.release)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
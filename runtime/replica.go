@@ -0,0 +1,100 @@
+package runtime
+
+import (
+	"context"
+	"fmt"
+	goruntime "runtime"
+	"slices"
+)
+
+type replicaContext struct{}
+
+// ReplicaFromContext 返回副本伴生协程的序号（从 0 开始），非副本伴生协程返回 false
+func ReplicaFromContext(ctx context.Context) (index int, ok bool) {
+	index, ok = ctx.Value(replicaContext{}).(int)
+	return
+}
+
+// PerCPU 返回 GOMAXPROCS 的 multiplier 倍作为副本数量，至少为 1
+func PerCPU(multiplier int) int {
+	return max(goruntime.GOMAXPROCS(0)*multiplier, 1)
+}
+
+// Replicas 一组相同的副本伴生协程，例如 N 个相同的消费者，副本名称为组名加序号，例如 consumer-0，
+// 副本均属于同名的组（见 Group），可在运行中扩缩容
+type Replicas struct {
+	runtime *Runtime
+	name    string
+	routine Routine
+	options []RoutineOption
+	tasks   []*task // 由运行时的锁保护
+	next    int     // 下一个副本的序号，由运行时的锁保护
+}
+
+// CoReplicas 增加 n 个相同的副本伴生协程，每个副本在上下文中携带其序号（见 ReplicaFromContext），
+// options 应用于每个副本，n 可以通过 PerCPU 按 GOMAXPROCS 计算，运行后增加的副本立即启动
+func (r *Runtime) CoReplicas(name string, n int, routine Routine, options ...RoutineOption) *Replicas {
+	g := &Replicas{runtime: r, name: name, routine: routine, options: options}
+	if routine != nil {
+		g.Scale(n)
+	}
+	return g
+}
+
+// Name 返回组名
+func (g *Replicas) Name() string {
+	return g.name
+}
+
+// Size 返回当前的副本数量
+func (g *Replicas) Size() int {
+	g.runtime.mu.Lock()
+	defer g.runtime.mu.Unlock()
+	return len(g.tasks)
+}
+
+// Scale 将副本数量调整为 n，扩容时增加序号递增的副本，运行后立即启动，
+// 缩容时结束序号最大的副本的上下文，副本退出后从运行时中移除；
+// 序号不会复用，缩容后再扩容的副本使用新的序号，避免与尚未退出的副本重名
+func (g *Replicas) Scale(n int) {
+	n = max(n, 0)
+	r := g.runtime
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for len(g.tasks) < n {
+		i := g.next
+		g.next++
+		t := newTask(func(ctx context.Context) error {
+			return g.routine(context.WithValue(ctx, replicaContext{}, i))
+		}, append(slices.Clone(g.options), Group(g.name))...)
+		t.status.Name = fmt.Sprintf("%s-%d", g.name, i) // 副本名称不可被选项覆盖
+		g.tasks = append(g.tasks, t)
+		r.add(t)
+	}
+	for _, t := range slices.Backward(g.tasks[min(n, len(g.tasks)):]) {
+		t.retired = true
+		if t.cancel != nil {
+			t.cancel()
+		} else {
+			r.remove(t) // 未启动或已退出，直接移除
+		}
+	}
+	g.tasks = g.tasks[:min(n, len(g.tasks))]
+}
+
+// Status 返回各副本的状态快照，按序号排列
+func (g *Replicas) Status() []RoutineStatus {
+	g.runtime.mu.Lock()
+	tasks := slices.Clone(g.tasks)
+	g.runtime.mu.Unlock()
+	list := make([]RoutineStatus, 0, len(tasks))
+	for _, t := range tasks {
+		list = append(list, t.status.snapshot())
+	}
+	return list
+}
+
+// CoReplicas 为默认运行时增加 n 个相同的副本伴生协程
+func CoReplicas(name string, n int, routine Routine, options ...RoutineOption) *Replicas {
+	return runtime.CoReplicas(name, n, routine, options...)
+}
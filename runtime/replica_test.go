@@ -0,0 +1,113 @@
+package runtime
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestReplicas(t *testing.T) {
+	r := New(WithQuorum("consumer", 1))
+	var mu sync.Mutex
+	running := map[int]bool{}
+	g := r.CoReplicas("consumer", 2, func(ctx context.Context) error {
+		i, _ := ReplicaFromContext(ctx)
+		mu.Lock()
+		running[i] = true
+		mu.Unlock()
+		<-ctx.Done()
+		mu.Lock()
+		running[i] = false
+		mu.Unlock()
+		return nil
+	}, Restart(RestartAlways), WithBackoff(Backoff{}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := r.run(ctx)
+	g.Scale(4)
+	wait := func(n int) {
+		for {
+			var count int
+			for _, s := range g.Status() {
+				if s.State == StateRunning {
+					count++
+				}
+			}
+			if count == n && len(r.Routines()) == n {
+				return
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	wait(4)
+	if s := g.Status(); s[3].Name != "consumer-3" || s[3].Group != "consumer" {
+		t.Errorf("unexpected replica status: %+v", s[3])
+	}
+	g.Scale(1)
+	wait(1)
+	mu.Lock()
+	if !running[0] || running[1] || running[3] {
+		t.Errorf("running = %v, want only replica 0", running)
+	}
+	mu.Unlock()
+
+	cancel()
+	for e := range c {
+		t.Errorf("unexpected error: %v", e)
+	}
+}
+
+func TestRoutineNames(t *testing.T) {
+	r := New()
+	noop := func(ctx context.Context) error { return nil }
+	r.Co(noop)
+	g := r.CoReplicas("c", 1, noop)
+	r.Co(noop)
+	g.Scale(0)
+	r.Co(noop)
+	var names []string
+	for _, s := range r.Routines() {
+		names = append(names, s.Name)
+	}
+	if want := []string{"routine-1", "routine-3", "routine-4"}; !slices.Equal(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+}
+
+func TestReplicaRescale(t *testing.T) {
+	r := New()
+	release := make(chan struct{})
+	g := r.CoReplicas("worker", 2, func(ctx context.Context) error {
+		<-ctx.Done()
+		if i, _ := ReplicaFromContext(ctx); i == 1 {
+			<-release // 模拟缓慢退出
+		}
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	c := r.run(ctx)
+
+	// 副本 worker-1 尚未退出时再次扩容，新副本不应与其重名
+	g.Scale(1)
+	g.Scale(2)
+	var names []string
+	for _, s := range r.Routines() {
+		names = append(names, s.Name)
+	}
+	if want := []string{"worker-0", "worker-1", "worker-2"}; !slices.Equal(names, want) {
+		t.Errorf("names = %v, want %v", names, want)
+	}
+	if s := g.Status(); len(s) != 2 || s[1].Name != "worker-2" {
+		t.Errorf("unexpected replica status: %+v", s)
+	}
+
+	close(release)
+	cancel()
+	for e := range c {
+		t.Errorf("unexpected error: %v", e)
+	}
+}
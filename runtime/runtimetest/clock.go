@@ -0,0 +1,126 @@
+package runtimetest
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keepitlight/kratos/runtime"
+)
+
+// Clock 可控的时钟，时间仅在调用 Advance 时前进，到期的计时器随之触发，
+// 配合 WaitTimers 可在不休眠的情况下驱动伴生协程的重启退避、定时任务及租约续约
+type Clock struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []*timer
+}
+
+// NewClock 创建以 now 为当前时间的可控时钟
+func NewClock(now time.Time) *Clock {
+	c := &Clock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *Clock) NewTimer(d time.Duration) runtime.Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &timer{clock: c, when: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	c.cond.Broadcast()
+	return t
+}
+
+// Advance 将时间前进 d，按到期时间依次触发到期的计时器
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	slices.SortStableFunc(c.timers, func(a, b *timer) int { return a.when.Compare(b.when) })
+	i := 0
+	for i < len(c.timers) && !c.timers[i].when.After(c.now) {
+		c.timers[i].c <- c.timers[i].when
+		i++
+	}
+	c.timers = slices.Delete(c.timers, 0, i)
+	c.cond.Broadcast()
+}
+
+// BlockUntil 阻塞直到至少有 n 个等待中的计时器，即伴生协程均已进入等待，没有期限，
+// 测试中优先使用 WaitTimers
+func (c *Clock) BlockUntil(n int) {
+	_ = c.BlockUntilContext(context.Background(), n)
+}
+
+// BlockUntilContext 阻塞直到至少有 n 个等待中的计时器，ctx 结束时返回其错误
+func (c *Clock) BlockUntilContext(ctx context.Context, n int) error {
+	stop := context.AfterFunc(ctx, func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.cond.Broadcast()
+	})
+	defer stop()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.timers) < n {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		c.cond.Wait()
+	}
+	return nil
+}
+
+// WaitTimers 阻塞直到至少有 n 个等待中的计时器，WaitTimeout 期限内未达到时测试失败
+func (c *Clock) WaitTimers(t testing.TB, n int) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), WaitTimeout)
+	defer cancel()
+	if err := c.BlockUntilContext(ctx, n); err != nil {
+		t.Fatalf("runtimetest: %d timers not waiting within %v, got %d", n, WaitTimeout, c.Timers())
+	}
+}
+
+// Timers 返回等待中的计时器数量
+func (c *Clock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+type timer struct {
+	clock *Clock
+	when  time.Time
+	c     chan time.Time
+}
+
+func (t *timer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *timer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	c.cond.Broadcast()
+	return true
+}
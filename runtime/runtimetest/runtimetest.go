@@ -0,0 +1,238 @@
+// Package runtimetest 提供运行时的测试工具：隔离的运行时、可控的时钟、可逐步驱动的伴生协程，
+// 以及对消息通道及延迟函数的断言，使伴生协程、预加载及延迟函数的生命周期可以在不休眠的情况下进行单元测试
+package runtimetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/log"
+	"github.com/keepitlight/kratos/runtime"
+)
+
+var (
+	// Epoch 可控时钟的默认起始时间
+	Epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// WaitTimeout 等待消息通道及伴生协程的期限，超出期限视为测试失败，避免测试挂起
+	WaitTimeout = 5 * time.Second
+)
+
+// Runtime 隔离的测试运行时，使用可控的时钟，不监听操作系统信号，日志默认丢弃，
+// 测试结束时自动退出运行时
+type Runtime struct {
+	*runtime.Runtime
+	Clock *Clock
+
+	t      testing.TB
+	cancel context.CancelFunc
+
+	mu     sync.Mutex
+	errs   []error       // 已收到的错误
+	next   int           // 下一个待断言的错误
+	notify chan struct{} // 收到新错误或通道关闭
+	closed bool          // 消息通道已关闭
+}
+
+// New 创建隔离的测试运行时，options 在默认选项之后应用，可覆盖默认的时钟及日志记录器
+func New(t testing.TB, options ...runtime.Option) *Runtime {
+	t.Helper()
+	clock := NewClock(Epoch)
+	defaults := []runtime.Option{
+		runtime.WithClock(clock),
+		runtime.WithSignals(make(chan os.Signal)),
+		runtime.WithLogger(log.NewStdLogger(io.Discard)),
+	}
+	r := &Runtime{
+		Runtime: runtime.New(append(defaults, options...)...),
+		Clock:   clock,
+		t:       t,
+		notify:  make(chan struct{}, 1),
+	}
+	t.Cleanup(func() {
+		_ = r.Stop()
+	})
+	return r
+}
+
+// Install 以测试运行时替换默认运行时，使包级函数（runtime.Co、runtime.Defer 等）作用于测试运行时，
+// 测试结束时恢复
+func (r *Runtime) Install() *Runtime {
+	previous := runtime.Replace(r.Runtime)
+	r.t.Cleanup(func() { runtime.Replace(previous) })
+	return r
+}
+
+// Start 执行预加载步骤并启动伴生协程，预加载失败时测试失败
+func (r *Runtime) Start() {
+	r.t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	c, err, ok := r.Runtime.Start(ctx, nil, nil, "", "", r.Clock.Now())
+	if err != nil || !ok {
+		r.t.Fatalf("runtimetest: start: %v (ok=%t)", err, ok)
+	}
+	go func() {
+		for err := range c {
+			r.mu.Lock()
+			r.errs = append(r.errs, err)
+			r.mu.Unlock()
+			r.signal()
+		}
+		r.mu.Lock()
+		r.closed = true
+		r.mu.Unlock()
+		r.signal()
+	}()
+}
+
+func (r *Runtime) signal() {
+	select {
+	case r.notify <- struct{}{}:
+	default:
+	}
+}
+
+// NextError 返回消息通道中下一个未断言的错误，期限内未收到或通道已关闭时测试失败
+func (r *Runtime) NextError() error {
+	r.t.Helper()
+	deadline := time.NewTimer(WaitTimeout)
+	defer deadline.Stop()
+	for {
+		r.mu.Lock()
+		if r.next < len(r.errs) {
+			err := r.errs[r.next]
+			r.next++
+			r.mu.Unlock()
+			return err
+		}
+		closed := r.closed
+		r.mu.Unlock()
+		if closed {
+			r.t.Fatal("runtimetest: channel closed, no more errors")
+		}
+		select {
+		case <-r.notify:
+		case <-deadline.C:
+			r.t.Fatalf("runtimetest: no error received within %v", WaitTimeout)
+		}
+	}
+}
+
+// ExpectError 断言消息通道中的下一个错误包装了 target，返回其 *runtime.RoutineError（若有）
+func (r *Runtime) ExpectError(target error) *runtime.RoutineError {
+	r.t.Helper()
+	err := r.NextError()
+	if !errors.Is(err, target) {
+		r.t.Fatalf("runtimetest: error = %v, want %v", err, target)
+	}
+	var e *runtime.RoutineError
+	errors.As(err, &e)
+	return e
+}
+
+// ExpectPanic 断言消息通道中的下一个错误为伴生协程的 panic，返回其 *runtime.RoutineError
+func (r *Runtime) ExpectPanic() *runtime.RoutineError {
+	r.t.Helper()
+	e := r.ExpectError(runtime.ErrPanic)
+	if e == nil || !e.Panicked() {
+		r.t.Fatalf("runtimetest: error %v is not a routine panic", e)
+	}
+	return e
+}
+
+// ExpectNoError 断言当前没有未断言的错误
+func (r *Runtime) ExpectNoError() {
+	r.t.Helper()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next < len(r.errs) {
+		r.t.Fatalf("runtimetest: unexpected errors: %v", r.errs[r.next:])
+	}
+}
+
+// Errors 返回已收到的所有错误
+func (r *Runtime) Errors() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.errs...)
+}
+
+// Stop 退出运行时：结束运行时上下文，等待伴生协程退出并执行延迟函数，仅执行一次
+func (r *Runtime) Stop() error {
+	if r.cancel != nil {
+		r.cancel()
+	}
+	return r.DisposeContext(context.Background())
+}
+
+// ExpectDefersRan 退出运行时并断言所有延迟函数均已执行完毕且未失败
+func (r *Runtime) ExpectDefersRan() {
+	r.t.Helper()
+	_ = r.Stop()
+	for _, d := range r.Defers() {
+		switch {
+		case !d.Done:
+			r.t.Errorf("runtimetest: defer %s did not run", d.Name)
+		case d.Err != nil:
+			r.t.Errorf("runtimetest: defer %s failed: %v", d.Name, d.Err)
+		}
+	}
+}
+
+// Routine 可逐步驱动的伴生协程，每次运行阻塞直到测试通过 Fail、Panic 或 Return 指定本次运行的结果，
+// 上下文结束时返回上下文的错误
+type Routine struct {
+	steps   chan func() error
+	started chan struct{}
+}
+
+// NewRoutine 创建可逐步驱动的伴生协程，通过 Run 方法登记到运行时
+func NewRoutine() *Routine {
+	return &Routine{steps: make(chan func() error), started: make(chan struct{}, 64)}
+}
+
+// Run 伴生协程函数
+func (r *Routine) Run(ctx context.Context) error {
+	select {
+	case r.started <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case step := <-r.steps:
+		return step()
+	}
+}
+
+// WaitStarted 阻塞直到伴生协程开始一次运行，期限内未开始时测试失败
+func (r *Routine) WaitStarted(t testing.TB) {
+	t.Helper()
+	select {
+	case <-r.started:
+	case <-time.After(WaitTimeout):
+		t.Fatalf("runtimetest: routine not started within %v", WaitTimeout)
+	}
+}
+
+// Fail 使当前的运行返回 err
+func (r *Routine) Fail(err error) {
+	r.steps <- func() error { return err }
+}
+
+// Panic 使当前的运行以 v panic
+func (r *Routine) Panic(v any) {
+	r.steps <- func() error { panic(v) }
+}
+
+// Return 使当前的运行正常返回
+func (r *Routine) Return() {
+	r.steps <- func() error { return nil }
+}
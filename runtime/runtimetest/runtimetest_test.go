@@ -0,0 +1,93 @@
+package runtimetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/keepitlight/kratos/runtime"
+)
+
+func TestRestartWithFakeClock(t *testing.T) {
+	r := New(t)
+	worker := NewRoutine()
+	r.CoWith(worker.Run, runtime.Name("worker"), runtime.Restart(runtime.RestartOnFailure),
+		runtime.WithBackoff(runtime.Backoff{Initial: time.Minute, Multiplier: 2}))
+	var closed bool
+	r.DeferWith("db", func(context.Context) error {
+		closed = true
+		return nil
+	})
+	r.Start()
+
+	failure := errors.New("failure")
+	worker.WaitStarted(t)
+	worker.Fail(failure)
+	if e := r.ExpectError(failure); e.Routine != "worker" || e.Attempt != 1 || !e.Time.Equal(Epoch) {
+		t.Errorf("unexpected routine error: %+v", e)
+	}
+
+	r.Clock.WaitTimers(t, 1)
+	r.Clock.Advance(time.Minute)
+	worker.WaitStarted(t)
+	worker.Panic("boom")
+	if e := r.ExpectPanic(); e.Attempt != 2 {
+		t.Errorf("attempt = %d, want 2", e.Attempt)
+	}
+
+	r.Clock.WaitTimers(t, 1)
+	r.Clock.Advance(time.Minute) // 退避翻倍，尚未重启
+	if r.Clock.Timers() != 1 {
+		t.Fatal("routine restarted before backoff elapsed")
+	}
+	r.Clock.Advance(time.Minute)
+	worker.WaitStarted(t)
+	r.ExpectNoError()
+
+	r.ExpectDefersRan()
+	if !closed {
+		t.Error("defer not run")
+	}
+}
+
+func TestScheduleWithFakeClock(t *testing.T) {
+	r := New(t).Install()
+	runs := make(chan time.Time, 10)
+	// 任务发送结果后尚未记录完成时时钟可能已前进，排队执行以免被视为重叠而跳过
+	runtime.CoSchedule(runtime.Every(time.Hour), func(ctx context.Context) error {
+		runs <- r.Clock.Now()
+		return nil
+	}, runtime.Overlap(runtime.OverlapQueue))
+	r.Start()
+
+	for i := 1; i <= 3; i++ {
+		r.Clock.WaitTimers(t, 1)
+		r.Clock.Advance(time.Hour)
+		if at := <-runs; !at.Equal(Epoch.Add(time.Duration(i) * time.Hour)) {
+			t.Errorf("run %d at %v", i, at)
+		}
+	}
+	r.ExpectDefersRan()
+	if s := r.Routines()[0]; s.Runs != 3 {
+		t.Errorf("runs = %d, want 3", s.Runs)
+	}
+}
+
+func TestRoutineCanceled(t *testing.T) {
+	worker := NewRoutine()
+	for range cap(worker.started) { // 开始运行的通知均未被观察
+		worker.started <- struct{}{}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := worker.Run(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("run = %v, want context.Canceled", err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	if err := NewClock(Epoch).BlockUntilContext(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("block until = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -1,5 +1,15 @@
 package runtime
 
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-kratos/kratos/v2/config"
+)
+
 type SceneType int
 
 const (
@@ -10,6 +20,201 @@ const (
 	SceneRel                   // 生产
 )
 
+const (
+	// SceneEnv 默认读取场景的环境变量
+	SceneEnv = "KRATOS_SCENE"
+	// SceneFlagName 默认读取场景的命令行参数
+	SceneFlagName = "scene"
+	// SceneKey 默认读取场景的配置键
+	SceneKey = "scene"
+)
+
 var (
 	Scene SceneType = SceneDev // 默认为开发场景
+
+	ErrScene       = errors.New("[kratos/runtime]unknown scene")
+	ErrSceneFrozen = errors.New("[kratos/runtime]scene frozen after runtime started")
+
+	buildScene string // 通过构建标签 scene_dev、scene_test、scene_demo、scene_pre、scene_rel 指定的场景
+
+	sceneNames = [...]string{"dev", "test", "demo", "pre", "rel"}
+	// sceneAliases 场景名称的别名
+	sceneAliases = map[string]SceneType{
+		"development": SceneDev,
+		"testing":     SceneTest,
+		"staging":     ScenePre,
+		"preprod":     ScenePre,
+		"prod":        SceneRel,
+		"production":  SceneRel,
+		"release":     SceneRel,
+	}
 )
+
+func (s SceneType) String() string {
+	if s >= 0 && int(s) < len(sceneNames) {
+		return sceneNames[s]
+	}
+	return fmt.Sprintf("SceneType(%d)", int(s))
+}
+
+// ParseScene 解析场景名称，不区分大小写，支持 dev、test、demo、pre、rel 及
+// development、testing、staging、preprod、prod、production、release 等别名
+func ParseScene(name string) (SceneType, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for i, n := range sceneNames {
+		if n == name {
+			return SceneType(i), nil
+		}
+	}
+	if s, ok := sceneAliases[name]; ok {
+		return s, nil
+	}
+	return SceneDev, fmt.Errorf("%w: %q", ErrScene, name)
+}
+
+func (s SceneType) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(sceneNames) {
+		return nil, fmt.Errorf("%w: %d", ErrScene, int(s))
+	}
+	return []byte(s.String()), nil
+}
+
+func (s *SceneType) UnmarshalText(text []byte) error {
+	v, err := ParseScene(string(text))
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// SceneOption 场景解析选项
+type SceneOption func(o *sceneOptions)
+
+type sceneOptions struct {
+	env   string
+	flags *flag.FlagSet
+	flag  string
+	conf  config.Config
+	key   string
+}
+
+// SceneFromEnv 指定读取场景的环境变量，未指定时为 SceneEnv，为空时不读取环境变量
+func SceneFromEnv(name string) SceneOption {
+	return func(o *sceneOptions) {
+		o.env = name
+	}
+}
+
+// SceneFromFlag 指定读取场景的命令行参数，仅读取已解析且显式指定的参数，
+// 未指定时读取 flag.CommandLine 的 SceneFlagName 参数
+func SceneFromFlag(flags *flag.FlagSet, name string) SceneOption {
+	return func(o *sceneOptions) {
+		o.flags, o.flag = flags, name
+	}
+}
+
+// SceneFromConfig 指定读取场景的 kratos 配置及配置键，键为空时使用 SceneKey
+func SceneFromConfig(c config.Config, key string) SceneOption {
+	return func(o *sceneOptions) {
+		if key == "" {
+			key = SceneKey
+		}
+		o.conf, o.key = c, key
+	}
+}
+
+// ResolveScene 解析场景，优先级从高到低依次为：命令行参数、环境变量、kratos 配置、构建标签，
+// 均未指定时返回当前的 Scene，任一来源的值无效时返回 ErrScene
+func ResolveScene(options ...SceneOption) (SceneType, error) {
+	o := &sceneOptions{env: SceneEnv, flags: flag.CommandLine, flag: SceneFlagName}
+	for _, option := range options {
+		if option != nil {
+			option(o)
+		}
+	}
+
+	type source struct {
+		name  string
+		value string
+	}
+	var sources []source
+	if o.flags != nil && o.flags.Parsed() {
+		o.flags.Visit(func(f *flag.Flag) {
+			if f.Name == o.flag {
+				sources = append(sources, source{"flag -" + o.flag, f.Value.String()})
+			}
+		})
+	}
+	if o.env != "" {
+		if v, ok := os.LookupEnv(o.env); ok && v != "" {
+			sources = append(sources, source{"env " + o.env, v})
+		}
+	}
+	if o.conf != nil {
+		if v, err := o.conf.Value(o.key).String(); err == nil && v != "" {
+			sources = append(sources, source{"config " + o.key, v})
+		}
+	}
+	if buildScene != "" {
+		sources = append(sources, source{"build tag", buildScene})
+	}
+
+	if len(sources) == 0 {
+		return Scene, nil
+	}
+	scenes := make([]SceneType, len(sources))
+	for i, src := range sources {
+		s, err := ParseScene(src.value)
+		if err != nil {
+			return SceneDev, fmt.Errorf("%w (from %s)", err, src.name)
+		}
+		scenes[i] = s
+	}
+	return scenes[0], nil
+}
+
+// WithScene 指定运行时的场景，未指定时在启动时取当前的 Scene
+func WithScene(scene SceneType) Option {
+	return func(r *Runtime) {
+		r.scene, r.sceneSet = scene, true
+	}
+}
+
+// Scene 返回运行时的场景，启动后场景固定不变
+func (r *Runtime) Scene() SceneType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started || r.sceneSet {
+		return r.scene
+	}
+	return Scene
+}
+
+// SetScene 设置运行时的场景，启动后返回 ErrSceneFrozen
+func (r *Runtime) SetScene(scene SceneType) error {
+	if _, err := scene.MarshalText(); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return ErrSceneFrozen
+	}
+	r.scene, r.sceneSet = scene, true
+	return nil
+}
+
+// SetScene 设置默认运行时的场景并同步到 Scene，默认运行时启动后返回 ErrSceneFrozen
+func SetScene(scene SceneType) error {
+	if err := runtime.SetScene(scene); err != nil {
+		return err
+	}
+	Scene = scene
+	return nil
+}
+
+// CurrentScene 返回默认运行时的场景，启动后不受对 Scene 的修改影响
+func CurrentScene() SceneType {
+	return runtime.Scene()
+}
@@ -0,0 +1,7 @@
+//go:build scene_demo
+
+package runtime
+
+func init() {
+	buildScene = "demo"
+}
@@ -0,0 +1,7 @@
+//go:build scene_dev
+
+package runtime
+
+func init() {
+	buildScene = "dev"
+}
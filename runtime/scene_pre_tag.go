@@ -0,0 +1,7 @@
+//go:build scene_pre
+
+package runtime
+
+func init() {
+	buildScene = "pre"
+}
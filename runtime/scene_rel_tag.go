@@ -0,0 +1,7 @@
+//go:build scene_rel
+
+package runtime
+
+func init() {
+	buildScene = "rel"
+}
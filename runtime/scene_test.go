@@ -0,0 +1,51 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/go-kratos/kratos/v2/config"
+	"github.com/go-kratos/kratos/v2/config/env"
+)
+
+func TestResolveScene(t *testing.T) {
+	t.Setenv("APP_SCENE", "staging")
+	c := config.New(config.WithSource(env.NewSource("APP_")))
+	if err := c.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.String("scene", "dev", "")
+
+	if s, err := ResolveScene(SceneFromFlag(flags, "scene"), SceneFromConfig(c, "SCENE")); err != nil || s != ScenePre {
+		t.Errorf("config scene = %v, %v, want pre", s, err)
+	}
+	t.Setenv(SceneEnv, "Production")
+	if s, err := ResolveScene(SceneFromFlag(flags, "scene"), SceneFromConfig(c, "SCENE")); err != nil || s != SceneRel {
+		t.Errorf("env scene = %v, %v, want rel", s, err)
+	}
+	flags.Parse([]string{"-scene", "dev"})
+	t.Setenv(SceneEnv, "qa")
+	if _, err := ResolveScene(SceneFromFlag(flags, "scene")); !errors.Is(err, ErrScene) {
+		t.Errorf("invalid env under flag error = %v, want ErrScene", err)
+	}
+	t.Setenv(SceneEnv, "")
+	flags.Parse([]string{"-scene", "qa"})
+	if _, err := ResolveScene(SceneFromFlag(flags, "scene")); !errors.Is(err, ErrScene) {
+		t.Errorf("flag scene error = %v, want ErrScene", err)
+	}
+
+	var s SceneType
+	if err := s.UnmarshalText([]byte("demo")); err != nil || s != SceneDemo || s.String() != "demo" {
+		t.Errorf("unmarshal demo = %v, %v", s, err)
+	}
+	r := New(WithScene(SceneTest))
+	r.Start(context.Background(), nil, nil, "", "", time.Now())
+	if err := r.SetScene(SceneRel); !errors.Is(err, ErrSceneFrozen) || r.Scene() != SceneTest {
+		t.Errorf("set scene after start = %v, scene = %v", err, r.Scene())
+	}
+}
@@ -0,0 +1,7 @@
+//go:build scene_test
+
+package runtime
+
+func init() {
+	buildScene = "test"
+}
@@ -0,0 +1,161 @@
+package runtime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Schedule 定时任务的调度计划
+type Schedule interface {
+	// Next 返回晚于 t 的下一次执行时间，返回零值表示不再执行
+	Next(t time.Time) time.Time
+}
+
+// Job 定时任务
+type Job func(ctx context.Context) error
+
+// OverlapPolicy 定时任务上一次执行尚未完成时的处理策略
+type OverlapPolicy int
+
+const (
+	OverlapSkip  OverlapPolicy = iota // 跳过本次执行，默认策略
+	OverlapQueue                      // 排队，上一次执行完成后立即执行
+)
+
+// interval 固定频率的调度计划，按计划时间计算下一次执行时间
+type interval time.Duration
+
+func (d interval) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// delay 固定延迟的调度计划，按上一次执行完成的时间计算下一次执行时间
+type delay time.Duration
+
+func (d delay) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(d))
+}
+
+// Every 返回固定频率的调度计划，每隔 d 执行一次，不论上一次执行耗时多久
+func Every(d time.Duration) Schedule {
+	return interval(d)
+}
+
+// Delay 返回固定延迟的调度计划，上一次执行完成 d 后再次执行，执行不会重叠
+func Delay(d time.Duration) Schedule {
+	return delay(d)
+}
+
+// Overlap 指定定时任务执行重叠时的处理策略
+func Overlap(policy OverlapPolicy) RoutineOption {
+	return func(t *task) {
+		t.overlap = policy
+	}
+}
+
+// schedule 按调度计划执行定时任务，直到上下文结束或调度计划结束，返回前等待执行中的任务完成
+func (t *task) schedule(ctx context.Context, schedule Schedule, job Job) error {
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		running bool // 是否有执行中的任务
+		queued  int  // 排队等待执行的次数
+	)
+	defer wg.Wait()
+	_, fixedDelay := schedule.(delay)
+
+	next := schedule.Next(t.clock.Now())
+	for !next.IsZero() {
+		t.status.update(func(s *RoutineStatus) { s.NextRun = next })
+		if !sleep(ctx, t.clock, next.Sub(t.clock.Now())) {
+			return nil
+		}
+		if fixedDelay {
+			t.run(ctx, job)
+			next = schedule.Next(t.clock.Now())
+			continue
+		}
+
+		mu.Lock()
+		switch {
+		case ctx.Err() != nil:
+			mu.Unlock()
+			return nil
+		case !running:
+			running = true
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					t.run(ctx, job)
+					mu.Lock()
+					if queued == 0 || ctx.Err() != nil {
+						running, queued = false, 0
+						mu.Unlock()
+						return
+					}
+					queued--
+					mu.Unlock()
+				}
+			}()
+		case t.overlap == OverlapQueue:
+			queued++
+		default:
+			t.status.update(func(s *RoutineStatus) { s.Skipped++ })
+		}
+		mu.Unlock()
+
+		// 落后于计划时跳过错过的执行时间
+		now := t.clock.Now()
+		if next = schedule.Next(next); !next.IsZero() && next.Before(now) {
+			next = schedule.Next(now)
+		}
+	}
+	t.status.update(func(s *RoutineStatus) { s.NextRun = time.Time{} })
+	return nil
+}
+
+// run 执行一次定时任务，记录执行时间、耗时及错误
+func (t *task) run(ctx context.Context, job Job) {
+	begin := t.clock.Now()
+	err := protect(func() error { return job(ctx) })
+	var e *RoutineError
+	t.status.update(func(s *RoutineStatus) {
+		s.Runs++
+		s.LastRun = begin
+		s.LastDuration = t.clock.Now().Sub(begin)
+		s.LastRunError = nil
+		if err != nil {
+			e = t.wrap(err, s.Runs)
+			s.LastRunError = e
+			if e.Stack != nil {
+				s.Stack = e.Stack
+			}
+		}
+	})
+	if e != nil && t.report != nil {
+		t.report(e)
+	}
+}
+
+// CoSchedule 增加定时任务，定时任务作为伴生协程在运行时上下文中按调度计划执行，
+// 任务的执行次数、耗时及错误记录在伴生协程的状态中，任务的错误触发失败回调但不会导致伴生协程退出，
+// 运行时上下文结束后等待执行中的任务完成后退出
+func (r *Runtime) CoSchedule(schedule Schedule, job Job, options ...RoutineOption) {
+	if schedule == nil || job == nil {
+		return
+	}
+	t := newTask(nil, options...)
+	t.routine = func(ctx context.Context) error {
+		return t.schedule(ctx, schedule, job)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.add(t)
+}
+
+// CoSchedule 为默认运行时增加定时任务，调度计划可以是 Every、Delay 或 ParseCron 的返回值
+func CoSchedule(schedule Schedule, job Job, options ...RoutineOption) {
+	runtime.CoSchedule(schedule, job, options...)
+}
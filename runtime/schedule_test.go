@@ -0,0 +1,97 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 15, 30, 0, time.UTC)
+	for _, c := range []struct {
+		expr string
+		want time.Time
+	}{
+		{"*/5 * * * *", time.Date(2024, 1, 31, 10, 20, 0, 0, time.UTC)},
+		{"30 * * * * *", time.Date(2024, 1, 31, 10, 16, 30, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, 2, 1, 9, 0, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * 7", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"CRON_TZ=Asia/Shanghai 0 0 * * *", time.Date(2024, 1, 31, 16, 0, 0, 0, time.UTC)},
+	} {
+		s, err := ParseCron(c.expr, time.UTC)
+		if err != nil {
+			t.Fatalf("ParseCron(%q): %v", c.expr, err)
+		}
+		if next := s.Next(base); !next.Equal(c.want) {
+			t.Errorf("ParseCron(%q).Next = %v, want %v", c.expr, next, c.want)
+		}
+	}
+	saturday := time.Date(2024, 1, 6, 0, 0, 1, 0, time.UTC)
+	for expr, want := range map[string]time.Time{
+		"0 0 * * 2-7/3": time.Date(2024, 1, 9, 0, 0, 0, 0, time.UTC), // 星期二、五，不含星期日
+		"0 0 * * 1-7/2": time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC), // 步进到达 7，含星期日
+		"0 0 * * 5-7":   time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+	} {
+		if next := MustParseCron(expr, time.UTC).Next(saturday); !next.Equal(want) {
+			t.Errorf("ParseCron(%q).Next = %v, want %v", expr, next, want)
+		}
+	}
+	for _, expr := range []string{"", "* * *", "60 * * * *", "* * * * * * *", "*/0 * * * *", "5-1 * * * *", "TZ=Nowhere/City * * * * *"} {
+		if _, err := ParseCron(expr, nil); !errors.Is(err, ErrCronSpec) {
+			t.Errorf("ParseCron(%q) error = %v, want ErrCronSpec", expr, err)
+		}
+	}
+}
+
+func TestCoSchedule(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs atomic.Int32
+	failure := errors.New("failure")
+	r := New()
+	r.CoSchedule(Every(5*time.Millisecond), func(ctx context.Context) error {
+		if runs.Add(1) == 3 {
+			cancel()
+		}
+		return failure
+	}, Name("ticker"))
+	r.CoSchedule(Every(time.Millisecond), func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	}, Name("slow"))
+
+	for _, e := range collect(ctx, r) {
+		t.Errorf("unexpected error: %v", e)
+	}
+	s := r.Routines()
+	if s[0].State != StateStopped || s[0].Runs < 3 || !errors.Is(s[0].LastRunError, failure) || s[0].LastRun.IsZero() {
+		t.Errorf("unexpected ticker status: %+v", s[0])
+	}
+	if s[1].Runs != 1 || s[1].Skipped == 0 {
+		t.Errorf("slow runs = %d, skipped = %d, want 1 and > 0", s[1].Runs, s[1].Skipped)
+	}
+}
+
+func TestCoScheduleDelay(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var runs int
+	r := New()
+	r.CoSchedule(Delay(time.Millisecond), func(ctx context.Context) error {
+		if runs++; runs == 2 {
+			cancel()
+		}
+		if runs == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+
+	collect(ctx, r)
+	s := r.Routines()[0]
+	if s.Runs != 2 || s.Stack == nil || s.LastRunError != nil {
+		t.Errorf("unexpected status: runs = %d, stack = %t, last error = %v", s.Runs, s.Stack != nil, s.LastRunError)
+	}
+}
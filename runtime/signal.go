@@ -0,0 +1,170 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	goruntime "runtime"
+	"strings"
+)
+
+// reloader 重新加载函数
+type reloader struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// WithSignals 指定运行时监听的信号来源，替代操作系统信号，用于在测试中注入模拟信号，
+// 信号的含义见 SignalReload、SignalDump 及 SignalDebug
+func WithSignals(c <-chan os.Signal) Option {
+	return func(r *Runtime) {
+		r.signals = c
+	}
+}
+
+// WithLogLevel 指定由 SignalDebug 切换的日志级别变量，通常同时传递给 slog.HandlerOptions
+func WithLogLevel(level *slog.LevelVar) Option {
+	return func(r *Runtime) {
+		r.level = level
+	}
+}
+
+// LogLevel 返回由 SignalDebug 切换的日志级别变量，传递给 slog.HandlerOptions 后即可通过信号切换调试日志
+func (r *Runtime) LogLevel() *slog.LevelVar {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.level == nil {
+		r.level = new(slog.LevelVar)
+	}
+	return r.level
+}
+
+// OnReload 登记重新加载函数，收到 SignalReload（SIGHUP）时按登记顺序执行，例如重新读取证书或配置，
+// 未命名的函数按登记顺序自动命名，运行时退出后登记返回 ErrDisposed
+func (r *Runtime) OnReload(name string, f func(ctx context.Context) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.disposed {
+		return ErrDisposed
+	}
+	if f == nil {
+		return nil
+	}
+	if name == "" {
+		name = fmt.Sprintf("reload-%d", len(r.reloads)+1)
+	}
+	r.reloads = append(r.reloads, &reloader{name: name, fn: f})
+	return nil
+}
+
+// Reload 按登记顺序执行所有重新加载函数，单个函数失败不影响其余函数，返回所有失败的聚合错误
+func (r *Runtime) Reload(ctx context.Context) error {
+	r.mu.Lock()
+	reloads := r.reloads
+	r.mu.Unlock()
+
+	var errs []error
+	for _, l := range reloads {
+		if err := protect(func() error { return l.fn(ctx) }); err != nil {
+			errs = append(errs, fmt.Errorf("[kratos/runtime]reload %s: %w", l.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Dump 将所有协程的堆栈及伴生协程的状态输出到日志
+func (r *Runtime) Dump() {
+	var b strings.Builder
+	b.WriteString("routines:\n")
+	for _, s := range r.Routines() {
+		fmt.Fprintf(&b, "  %s\t%s\trestarts=%d", s.Name, s.State, s.Restarts)
+		if s.LastError != nil {
+			fmt.Fprintf(&b, "\terror=%v", s.LastError)
+		}
+		b.WriteByte('\n')
+	}
+	b.WriteString("\ngoroutines:\n")
+	b.Write(Stacks())
+	r.log().Infof("[kratos/runtime]dump\n%s", b.String())
+}
+
+// Stacks 返回所有协程的堆栈
+func Stacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := goruntime.Stack(buf, true)
+		if n < len(buf) {
+			return bytes.TrimSpace(buf[:n])
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// ToggleDebug 在调试级别与原日志级别之间切换 LogLevel，返回切换后是否为调试级别
+func (r *Runtime) ToggleDebug() bool {
+	level := r.LogLevel()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if level.Level() > slog.LevelDebug {
+		r.base = level.Level()
+		level.Set(slog.LevelDebug)
+		return true
+	}
+	level.Set(r.base)
+	return false
+}
+
+// watch 监听信号直到上下文结束，未通过 WithSignals 指定信号来源时监听操作系统信号
+func (r *Runtime) watch(ctx context.Context) {
+	c := r.signals
+	if c == nil {
+		if len(notifiable) == 0 {
+			return
+		}
+		ch := make(chan os.Signal, 1)
+		signal.Notify(ch, notifiable...)
+		defer signal.Stop(ch)
+		c = ch
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig, ok := <-c:
+			if !ok {
+				return
+			}
+			r.handle(ctx, sig)
+		}
+	}
+}
+
+// handle 处理信号
+func (r *Runtime) handle(ctx context.Context, sig os.Signal) {
+	logger := r.log()
+	switch sig {
+	case SignalReload:
+		logger.Infof("[kratos/runtime]signal %v, reloading", sig)
+		if err := r.Reload(ctx); err != nil {
+			logger.Errorf("%v", err)
+		}
+	case SignalDump:
+		r.Dump()
+	case SignalDebug:
+		logger.Infof("[kratos/runtime]signal %v, debug logging: %t", sig, r.ToggleDebug())
+	}
+}
+
+// OnReload 为默认运行时登记重新加载函数，收到 SIGHUP 时执行，运行时退出后调用返回 ErrDisposed
+func OnReload(name string, f func(ctx context.Context) error) error {
+	return runtime.OnReload(name, f)
+}
+
+// LogLevel 返回默认运行时由 SIGUSR2 切换的日志级别变量
+func LogLevel() *slog.LevelVar {
+	return runtime.LogLevel()
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package runtime
+
+import "os"
+
+// synthetic 当前平台不支持的信号，仅可通过 WithSignals 注入
+type synthetic string
+
+func (s synthetic) String() string { return string(s) }
+func (s synthetic) Signal()        {}
+
+var (
+	SignalReload os.Signal = synthetic("reload") // 执行重新加载函数
+	SignalDump   os.Signal = synthetic("dump")   // 输出协程堆栈及伴生协程状态
+	SignalDebug  os.Signal = synthetic("debug")  // 切换调试日志
+
+	notifiable []os.Signal // 不监听操作系统信号
+)
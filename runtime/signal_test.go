@@ -0,0 +1,53 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSignals(t *testing.T) {
+	signals := make(chan os.Signal)
+	logger := &captured{}
+	r := New(WithSignals(signals), WithLogger(logger))
+	var reloaded atomic.Bool
+	r.OnReload("", func(ctx context.Context) error {
+		reloaded.Store(true)
+		return nil
+	})
+	r.OnReload("certs", func(ctx context.Context) error { return errors.New("bad cert") })
+	started := make(chan struct{})
+	r.Co(func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.run(ctx)
+	<-started
+	signals <- SignalDebug
+	signals <- SignalDump
+	signals <- SignalReload
+	signals <- os.Interrupt // 未处理的信号，接收即表示之前的信号已处理完毕
+
+	if !reloaded.Load() {
+		t.Error("reload hook not called")
+	}
+	if level := r.LogLevel().Level(); level != slog.LevelDebug {
+		t.Errorf("log level = %v, want debug", level)
+	}
+	logger.mu.Lock()
+	logs := strings.Join(logger.logs, "\n")
+	logger.mu.Unlock()
+	for _, want := range []string{"routine-1\trunning", "goroutine ", "reload certs: bad cert"} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("logs missing %q", want)
+		}
+	}
+}
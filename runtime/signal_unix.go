@@ -0,0 +1,16 @@
+//go:build unix
+
+package runtime
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	SignalReload os.Signal = syscall.SIGHUP  // 执行重新加载函数
+	SignalDump   os.Signal = syscall.SIGUSR1 // 输出协程堆栈及伴生协程状态
+	SignalDebug  os.Signal = syscall.SIGUSR2 // 切换调试日志
+
+	notifiable = []os.Signal{SignalReload, SignalDump, SignalDebug}
+)
@@ -0,0 +1,111 @@
+package runtime
+
+import (
+	"maps"
+	"sync"
+	"time"
+)
+
+// RoutineState 伴生协程的运行状态
+type RoutineState int
+
+const (
+	StatePending    RoutineState = iota // 等待运行时启动
+	StateRunning                        // 运行中
+	StateRestarting                     // 等待重启
+	StateStopped                        // 正常退出
+	StateFailed                         // 异常退出或放弃重启
+	StateStandby                        // 单例伴生协程等待获取租约
+)
+
+func (s RoutineState) String() string {
+	switch s {
+	case StatePending:
+		return "pending"
+	case StateRunning:
+		return "running"
+	case StateRestarting:
+		return "restarting"
+	case StateStopped:
+		return "stopped"
+	case StateFailed:
+		return "failed"
+	case StateStandby:
+		return "standby"
+	default:
+		return "unknown"
+	}
+}
+
+// RoutineStatus 伴生协程的状态快照
+type RoutineStatus struct {
+	Name        string            // 名称
+	Description string            // 描述
+	Labels      map[string]string // 标签
+	Critical    bool              // 是否为关键伴生协程
+	Group       string            // 所属的组
+	State       RoutineState      // 当前状态
+	Started     time.Time         // 最近一次启动的时间
+	LastError   error             // 最近一次的错误
+	Restarts    int               // 累计重启次数
+	Stack       []byte            // 最近一次 panic 的堆栈
+
+	// 定时任务的执行情况，仅对 CoSchedule 增加的伴生协程有效
+	Runs         int           // 累计执行次数
+	Skipped      int           // 因执行重叠而跳过的次数
+	LastRun      time.Time     // 最近一次执行的开始时间
+	LastDuration time.Duration // 最近一次执行的耗时
+	LastRunError error         // 最近一次执行的错误
+	NextRun      time.Time     // 下一次执行的计划时间
+}
+
+// status 伴生协程的实时状态，并发安全
+type status struct {
+	mu sync.RWMutex
+	RoutineStatus
+}
+
+func (s *status) update(f func(s *RoutineStatus)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&s.RoutineStatus)
+}
+
+func (s *status) snapshot() RoutineStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v := s.RoutineStatus
+	v.Labels = maps.Clone(v.Labels)
+	return v
+}
+
+// Name 指定伴生协程的名称，用于状态查询及错误信息，未指定时自动命名
+func Name(name string) RoutineOption {
+	return func(t *task) {
+		t.status.Name = name
+	}
+}
+
+// Describe 指定伴生协程的描述
+func Describe(description string) RoutineOption {
+	return func(t *task) {
+		t.status.Description = description
+	}
+}
+
+// Critical 标记为关键伴生协程，关键伴生协程失败时健康状态降级，失败策略为 FailFast 时关闭主程序
+func Critical() RoutineOption {
+	return func(t *task) {
+		t.status.Critical = true
+	}
+}
+
+// Labels 为伴生协程增加标签
+func Labels(labels map[string]string) RoutineOption {
+	return func(t *task) {
+		if t.status.Labels == nil {
+			t.status.Labels = make(map[string]string, len(labels))
+		}
+		maps.Copy(t.status.Labels, labels)
+	}
+}
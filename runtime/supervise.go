@@ -0,0 +1,264 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RestartPolicy 伴生协程的重启策略
+type RestartPolicy int
+
+const (
+	RestartNever     RestartPolicy = iota // 从不重启，默认策略，与 Co 的行为一致
+	RestartOnFailure                      // 返回错误或发生 panic 时重启
+	RestartAlways                         // 总是重启，直到上下文结束
+)
+
+var (
+	// ErrGiveUp 伴生协程超出重启预算后放弃重启，作为终止事件发送到消息通道
+	ErrGiveUp = errors.New("[kratos/runtime]routine gave up")
+
+	// DefaultBackoff 默认的重启退避配置
+	DefaultBackoff = Backoff{
+		Initial:    100 * time.Millisecond,
+		Max:        30 * time.Second,
+		Multiplier: 2,
+		Jitter:     0.2,
+	}
+)
+
+// Backoff 重启的指数退避配置
+type Backoff struct {
+	Initial    time.Duration // 首次重启前的等待时间
+	Max        time.Duration // 最大等待时间
+	Multiplier float64       // 每次重启等待时间的增长倍数，小于 1 时视为 1
+	Jitter     float64       // 随机抖动比例，取值 [0, 1]，避免多个协程同时重启
+}
+
+// Delay 返回第 attempt 次（从 0 开始）重启前的等待时间
+func (b Backoff) Delay(attempt int) time.Duration {
+	if b.Initial <= 0 {
+		return 0
+	}
+	m := b.Multiplier
+	if m < 1 {
+		m = 1
+	}
+	d := float64(b.Initial) * math.Pow(m, float64(attempt))
+	if b.Max > 0 && d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	if j := min(max(b.Jitter, 0), 1); j > 0 {
+		d += d * j * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+// RoutineOption 伴生协程选项
+type RoutineOption func(t *task)
+
+// Restart 指定伴生协程的重启策略
+func Restart(policy RestartPolicy) RoutineOption {
+	return func(t *task) {
+		t.policy = policy
+	}
+}
+
+// WithBackoff 指定伴生协程重启的退避配置，未指定时使用 DefaultBackoff
+func WithBackoff(backoff Backoff) RoutineOption {
+	return func(t *task) {
+		t.backoff = backoff
+	}
+}
+
+// MaxRestarts 指定在 window 时间窗口内最多重启 n 次，超出后放弃，
+// n 小于等于 0 表示不限次数，window 小于等于 0 表示不限时间窗口（累计计数）
+func MaxRestarts(n int, window time.Duration) RoutineOption {
+	return func(t *task) {
+		t.maxRestarts = n
+		t.window = window
+	}
+}
+
+// OnGiveUp 指定伴生协程放弃重启时的回调，参数 err 包装了 ErrGiveUp 及最后一次的错误
+func OnGiveUp(f func(err error)) RoutineOption {
+	return func(t *task) {
+		t.onGiveUp = f
+	}
+}
+
+// task 受监管的伴生协程
+type task struct {
+	routine     Routine
+	policy      RestartPolicy
+	backoff     Backoff
+	maxRestarts int
+	window      time.Duration
+	onGiveUp    func(err error)
+	singleton   *singleton                       // 单例伴生协程的租约配置
+	overlap     OverlapPolicy                    // 定时任务执行重叠时的处理策略
+	report      func(err *RoutineError)          // 失败时的回调，由运行时登记时设置
+	clock       Clock                            // 时钟，由运行时登记时设置
+	emit        func(e Event)                    // 发布生命周期事件，由运行时登记时设置
+	warn        func(format string, args ...any) // 记录警告日志，由运行时登记时设置
+	cancel      context.CancelFunc               // 结束伴生协程的上下文，运行期间有效，由运行时的锁保护
+	retired     bool                             // 已缩容，退出后移除，由运行时的锁保护
+
+	status status // 实时状态
+}
+
+func newTask(routine Routine, options ...RoutineOption) *task {
+	t := &task{routine: routine, backoff: DefaultBackoff, clock: realClock{}}
+	for _, option := range options {
+		if option != nil {
+			option(t)
+		}
+	}
+	return t
+}
+
+// call 执行一次伴生协程，将 panic 转换为 *RoutineError 返回
+func (t *task) call(ctx context.Context) error {
+	return protect(func() error { return t.routine(ctx) })
+}
+
+// exec 执行一次伴生协程，单例伴生协程仅在持有租约期间执行
+func (t *task) exec(ctx context.Context) error {
+	if t.singleton != nil {
+		return t.lead(ctx)
+	}
+	return t.call(ctx)
+}
+
+// fail 记录错误，将错误包装为 *RoutineError 通过 send 发送到消息通道并执行失败回调
+func (t *task) fail(send func(err error), err error, attempt int) {
+	e := t.wrap(err, attempt)
+	t.status.update(func(s *RoutineStatus) {
+		s.LastError = e
+		if e.Stack != nil {
+			s.Stack = e.Stack
+		}
+	})
+	if e.Panicked() {
+		t.event(EventRoutinePanicked, attempt, e)
+	}
+	send(e)
+	if t.report != nil {
+		t.report(e)
+	}
+}
+
+// event 发布伴生协程的生命周期事件
+func (t *task) event(typ EventType, attempt int, err error) {
+	if t.emit != nil {
+		t.emit(Event{Type: typ, Routine: t.status.Name, Attempt: attempt, Err: err})
+	}
+}
+
+// exit 记录伴生协程的最终状态
+func (t *task) exit(ctx context.Context, err error, attempt int) {
+	t.status.update(func(s *RoutineStatus) {
+		if err != nil && !errors.Is(err, ctx.Err()) {
+			s.State = StateFailed
+		} else {
+			s.State = StateStopped
+		}
+	})
+	t.event(EventRoutineExited, attempt, err)
+}
+
+// restartable 判断伴生协程退出后是否需要重启
+func (t *task) restartable(err error) bool {
+	switch t.policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// supervise 按重启策略执行伴生协程，错误及放弃事件通过 send 发送到消息通道
+func (t *task) supervise(ctx context.Context, send func(err error)) {
+	var restarts []time.Time // 时间窗口内的重启时间
+	attempt := 0             // 退避计算的重启次数
+	for runs := 1; ; runs++ {
+		begin := t.clock.Now()
+		t.status.update(func(s *RoutineStatus) {
+			s.State = StateRunning
+			s.Started = begin
+		})
+		t.event(EventRoutineStarted, runs, nil)
+		err := t.exec(ctx)
+		if err != nil && (ctx.Err() == nil || !errors.Is(err, ctx.Err())) { // 正常退出时返回的上下文错误不报告
+			t.fail(send, err, runs)
+		}
+		if ctx.Err() != nil || !t.restartable(err) {
+			t.exit(ctx, err, runs)
+			return
+		}
+
+		now := t.clock.Now()
+		if t.window > 0 {
+			restarts = pruneBefore(restarts, now.Add(-t.window))
+		}
+		if t.maxRestarts > 0 && len(restarts) >= t.maxRestarts {
+			e := fmt.Errorf("%w after %d restarts", ErrGiveUp, len(restarts))
+			if err != nil {
+				e = fmt.Errorf("%w: %w", e, err)
+			}
+			if t.onGiveUp != nil {
+				t.onGiveUp(e)
+			}
+			t.fail(send, e, runs)
+			t.status.update(func(s *RoutineStatus) { s.State = StateFailed })
+			t.event(EventRoutineExited, runs, e)
+			return
+		}
+		restarts = append(restarts, now)
+		t.status.update(func(s *RoutineStatus) {
+			s.State = StateRestarting
+			s.Restarts++
+		})
+
+		// 稳定运行超过最大退避时间后，退避重新计算
+		if t.backoff.Max > 0 && now.Sub(begin) > t.backoff.Max {
+			attempt = 0
+		}
+		if !sleep(ctx, t.clock, t.backoff.Delay(attempt)) {
+			t.exit(ctx, ctx.Err(), runs)
+			return
+		}
+		attempt++
+		t.event(EventRoutineRestarted, runs+1, err)
+	}
+}
+
+// pruneBefore 移除早于 deadline 的时间
+func pruneBefore(times []time.Time, deadline time.Time) []time.Time {
+	i := 0
+	for i < len(times) && times[i].Before(deadline) {
+		i++
+	}
+	return times[i:]
+}
+
+// sleep 按时钟 clock 等待 d 时长，上下文结束时返回 false
+func sleep(ctx context.Context, clock Clock, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C():
+		return ctx.Err() == nil
+	}
+}
@@ -0,0 +1,163 @@
+package runtime
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// collect 运行伴生协程并收集错误，直到所有伴生协程退出
+func collect(ctx context.Context, r *Runtime) (errs []error) {
+	ctx, cancel := context.WithCancel(ctx)
+	c := r.run(ctx)
+	go func() {
+		r.wg.Wait()
+		cancel()
+	}()
+	for e := range c {
+		errs = append(errs, e)
+	}
+	return
+}
+
+func TestSuperviseGiveUp(t *testing.T) {
+	var calls int
+	failure := errors.New("failure")
+	var gaveUp error
+	r := New()
+	r.CoWith(func(ctx context.Context) error {
+		calls++
+		return failure
+	},
+		Restart(RestartOnFailure),
+		WithBackoff(Backoff{Initial: time.Millisecond}),
+		MaxRestarts(2, time.Minute),
+		OnGiveUp(func(err error) { gaveUp = err }),
+	)
+
+	errs := collect(context.Background(), r)
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+	if len(errs) != 4 {
+		t.Fatalf("errors = %d, want 4", len(errs))
+	}
+	last := errs[len(errs)-1]
+	if !errors.Is(last, ErrGiveUp) || !errors.Is(last, failure) {
+		t.Errorf("last error = %v, want ErrGiveUp wrapping failure", last)
+	}
+	if !errors.Is(last, gaveUp) {
+		t.Errorf("OnGiveUp received %v, want %v", gaveUp, last)
+	}
+}
+
+func TestSuperviseRestartAlways(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls int
+	r := New()
+	r.CoWith(func(ctx context.Context) error {
+		calls++
+		if calls == 3 {
+			cancel()
+		}
+		return nil
+	}, Restart(RestartAlways), WithBackoff(Backoff{}))
+
+	for _, e := range collect(ctx, r) {
+		t.Errorf("unexpected error: %v", e)
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
+
+func TestSupervisePanic(t *testing.T) {
+	var calls int
+	var hooked *RoutineError
+	r := New(WithErrorHook(func(err *RoutineError) { hooked = err }))
+	r.CoWith(func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return nil
+	}, Name("worker"), Restart(RestartOnFailure), WithBackoff(Backoff{}))
+
+	errs := collect(context.Background(), r)
+	if calls != 2 || len(errs) != 1 {
+		t.Fatalf("calls = %d, errors = %d, want 2 and 1", calls, len(errs))
+	}
+	var e *RoutineError
+	if !errors.As(errs[0], &e) || !e.Panicked() || !errors.Is(e, ErrPanic) {
+		t.Fatalf("error = %v, want panicked *RoutineError", errs[0])
+	}
+	if e.Routine != "worker" || e.Panic != "boom" || e.Attempt != 1 || len(e.Stack) == 0 || e.Time.IsZero() {
+		t.Errorf("unexpected routine error: %+v", e)
+	}
+	if hooked != e {
+		t.Errorf("error hook received %v, want %v", hooked, e)
+	}
+}
+
+func TestSuperviseCanceled(t *testing.T) {
+	var hooked atomic.Int32
+	r := New(WithErrorHook(func(*RoutineError) { hooked.Add(1) }))
+	r.Co(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if errs := collect(ctx, r); len(errs) != 0 || hooked.Load() != 0 {
+		t.Errorf("errors = %v, hooks = %d, want none on graceful shutdown", errs, hooked.Load())
+	}
+	if s := r.Routines()[0]; s.State != StateStopped || s.LastError != nil {
+		t.Errorf("routine-1 = %v %v, want stopped without error", s.State, s.LastError)
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if d := b.Delay(i); d != want {
+			t.Errorf("Delay(%d) = %v, want %v", i, d, want)
+		}
+	}
+	b.Jitter = 0.5
+	for i := 0; i < 100; i++ {
+		if d := b.Delay(0); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("Delay with jitter = %v, out of range", d)
+		}
+	}
+}
+
+func TestRoutines(t *testing.T) {
+	failure := errors.New("failure")
+	r := New()
+	r.Co(func(ctx context.Context) error { return nil })
+	r.CoWith(func(ctx context.Context) error { return failure },
+		Name("consumer"), Describe("queue consumer"), Labels(map[string]string{"queue": "orders"}),
+		Restart(RestartOnFailure), WithBackoff(Backoff{}), MaxRestarts(1, 0),
+	)
+	if s := r.Routines(); s[0].Name != "routine-1" || s[1].State != StatePending {
+		t.Fatalf("unexpected status before start: %+v", s)
+	}
+
+	errs := collect(context.Background(), r)
+	for _, e := range errs {
+		if !strings.Contains(e.Error(), "consumer") {
+			t.Errorf("error %q does not carry routine name", e)
+		}
+	}
+	s := r.Routines()
+	if s[0].State != StateStopped {
+		t.Errorf("routine-1 state = %v, want stopped", s[0].State)
+	}
+	c := s[1]
+	if c.State != StateFailed || c.Restarts != 1 || !errors.Is(c.LastError, ErrGiveUp) || c.Labels["queue"] != "orders" {
+		t.Errorf("unexpected consumer status: %+v", c)
+	}
+}
@@ -0,0 +1,223 @@
+package runtime
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path"
+	goruntime "runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+const (
+	// TunePreload 自动调优预加载步骤的名称
+	TunePreload = "autotune"
+
+	// DefaultMemoryHeadroom 软内存上限相对容器内存限制预留的比例
+	DefaultMemoryHeadroom = 0.1
+)
+
+// Resources 容器（cgroup）的资源限制
+type Resources struct {
+	Cgroup      int     `json:"cgroup"`      // cgroup 版本，0 表示未检测到 cgroup
+	CPUQuota    float64 `json:"cpuQuota"`    // CPU 配额（核数），0 表示不限
+	MemoryLimit int64   `json:"memoryLimit"` // 内存限制（字节），0 表示不限
+}
+
+// Tuning 自动调优的结果
+type Tuning struct {
+	Resources
+	GOMAXPROCS      int   `json:"gomaxprocs"`      // 调优后的 GOMAXPROCS
+	PrevGOMAXPROCS  int   `json:"prevGomaxprocs"`  // 调优前的 GOMAXPROCS
+	SoftMemoryLimit int64 `json:"softMemoryLimit"` // 设置的软内存上限（字节），0 表示未设置
+}
+
+// TuneOption 自动调优选项
+type TuneOption func(o *tuneOptions)
+
+type tuneOptions struct {
+	fsys     fs.FS
+	headroom float64
+	minProcs int
+}
+
+// TuneFS 指定读取 cgroup 信息的文件系统，根目录对应 /，用于在测试中模拟 cgroup 文件系统
+func TuneFS(fsys fs.FS) TuneOption {
+	return func(o *tuneOptions) {
+		o.fsys = fsys
+	}
+}
+
+// MemoryHeadroom 指定软内存上限相对内存限制预留的比例，取值 [0, 1)，未指定时为 DefaultMemoryHeadroom
+func MemoryHeadroom(ratio float64) TuneOption {
+	return func(o *tuneOptions) {
+		o.headroom = ratio
+	}
+}
+
+// MinProcs 指定 GOMAXPROCS 的下限，未指定时为 1
+func MinProcs(n int) TuneOption {
+	return func(o *tuneOptions) {
+		o.minProcs = n
+	}
+}
+
+// ReadResources 从文件系统 fsys（根目录对应 /）中读取当前进程所在 cgroup v1 或 v2 的 CPU 配额及内存限制
+func ReadResources(fsys fs.FS) (Resources, error) {
+	groups, err := cgroups(fsys)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return Resources{}, nil // 非 Linux 或未运行在 cgroup 中
+		}
+		return Resources{}, err
+	}
+	if p, ok := groups[""]; ok {
+		return readV2(fsys, p)
+	}
+	return readV1(fsys, groups)
+}
+
+// cgroups 解析 /proc/self/cgroup，返回控制器到 cgroup 路径的映射，v2 的控制器为空字符串
+func cgroups(fsys fs.FS) (map[string]string, error) {
+	f, err := fsys.Open("proc/self/cgroup")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	groups := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			groups[""] = parts[2]
+			continue
+		}
+		for _, c := range strings.Split(parts[1], ",") {
+			groups[c] = parts[2]
+		}
+	}
+	return groups, scanner.Err()
+}
+
+// lookup 在 cgroup 路径下查找文件，找不到时回退到控制器的根目录（容器内启用 cgroup 命名空间时）
+func lookup(fsys fs.FS, dirs []string, group, name string) (string, error) {
+	for _, dir := range dirs {
+		for _, p := range []string{path.Join(dir, group, name), path.Join(dir, name)} {
+			if data, err := fs.ReadFile(fsys, p); err == nil {
+				return strings.TrimSpace(string(data)), nil
+			}
+		}
+	}
+	return "", fs.ErrNotExist
+}
+
+func readV2(fsys fs.FS, group string) (Resources, error) {
+	res := Resources{Cgroup: 2}
+	dirs := []string{"sys/fs/cgroup"}
+	if v, err := lookup(fsys, dirs, group, "cpu.max"); err == nil {
+		// $MAX $PERIOD，$MAX 为 max 表示不限
+		if quota, period, ok := strings.Cut(v, " "); ok && quota != "max" {
+			q, err1 := strconv.ParseFloat(quota, 64)
+			p, err2 := strconv.ParseFloat(period, 64)
+			if err := errors.Join(err1, err2); err != nil || p <= 0 {
+				return res, fmt.Errorf("[kratos/runtime]invalid cpu.max %q", v)
+			}
+			res.CPUQuota = q / p
+		}
+	}
+	if v, err := lookup(fsys, dirs, group, "memory.max"); err == nil && v != "max" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return res, fmt.Errorf("[kratos/runtime]invalid memory.max %q", v)
+		}
+		res.MemoryLimit = n
+	}
+	return res, nil
+}
+
+func readV1(fsys fs.FS, groups map[string]string) (Resources, error) {
+	res := Resources{Cgroup: 1}
+	cpu := []string{"sys/fs/cgroup/cpu", "sys/fs/cgroup/cpu,cpuacct"}
+	quota, err1 := lookup(fsys, cpu, groups["cpu"], "cpu.cfs_quota_us")
+	period, err2 := lookup(fsys, cpu, groups["cpu"], "cpu.cfs_period_us")
+	if err1 == nil && err2 == nil && quota != "-1" {
+		q, err1 := strconv.ParseFloat(quota, 64)
+		p, err2 := strconv.ParseFloat(period, 64)
+		if err := errors.Join(err1, err2); err != nil || p <= 0 {
+			return res, fmt.Errorf("[kratos/runtime]invalid cfs quota %q or period %q", quota, period)
+		}
+		res.CPUQuota = q / p
+	}
+	if v, err := lookup(fsys, []string{"sys/fs/cgroup/memory"}, groups["memory"], "memory.limit_in_bytes"); err == nil {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return res, fmt.Errorf("[kratos/runtime]invalid memory.limit_in_bytes %q", v)
+		}
+		if n < 1<<62 { // 不限时为接近 math.MaxInt64 的页对齐值
+			res.MemoryLimit = n
+		}
+	}
+	return res, nil
+}
+
+// tune 读取容器资源限制，设置 GOMAXPROCS 及软内存上限，
+// 已通过 GOMAXPROCS、GOMEMLIMIT 环境变量指定时不覆盖
+func (r *Runtime) tune(o *tuneOptions) error {
+	res, err := ReadResources(o.fsys)
+	if err != nil {
+		return err
+	}
+	t := Tuning{Resources: res, PrevGOMAXPROCS: goruntime.GOMAXPROCS(0)}
+	t.GOMAXPROCS = t.PrevGOMAXPROCS
+	if res.CPUQuota > 0 && os.Getenv("GOMAXPROCS") == "" {
+		t.GOMAXPROCS = max(int(math.Floor(res.CPUQuota)), o.minProcs, 1)
+		goruntime.GOMAXPROCS(t.GOMAXPROCS)
+	}
+	if res.MemoryLimit > 0 && os.Getenv("GOMEMLIMIT") == "" {
+		t.SoftMemoryLimit = int64(float64(res.MemoryLimit) * (1 - min(max(o.headroom, 0), 0.99)))
+		debug.SetMemoryLimit(t.SoftMemoryLimit)
+	}
+
+	r.mu.Lock()
+	r.tuning = t
+	r.mu.Unlock()
+	r.log().Infof("[kratos/runtime]autotune: cgroup v%d, cpu quota %.2f, GOMAXPROCS %d -> %d, memory limit %d, soft memory limit %d",
+		res.Cgroup, res.CPUQuota, t.PrevGOMAXPROCS, t.GOMAXPROCS, res.MemoryLimit, t.SoftMemoryLimit)
+	return nil
+}
+
+// AutoTune 登记名为 TunePreload 的预加载步骤，按容器（cgroup v1/v2）的资源限制设置 GOMAXPROCS 及软内存上限，
+// 其它预加载步骤可通过 DependsOn(TunePreload) 在调优后执行，调优结果通过 Tuning 获取
+func (r *Runtime) AutoTune(options ...TuneOption) error {
+	o := &tuneOptions{fsys: os.DirFS("/"), headroom: DefaultMemoryHeadroom, minProcs: 1}
+	for _, option := range options {
+		if option != nil {
+			option(o)
+		}
+	}
+	return r.PreloadWith(TunePreload, func(context.Context) error {
+		return r.tune(o)
+	})
+}
+
+// Tuning 返回自动调优的结果，未调优时为零值
+func (r *Runtime) Tuning() Tuning {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.tuning
+}
+
+// AutoTune 为默认运行时登记自动调优的预加载步骤
+func AutoTune(options ...TuneOption) error {
+	return runtime.AutoTune(options...)
+}
@@ -0,0 +1,73 @@
+package runtime
+
+import (
+	"context"
+	goruntime "runtime"
+	"runtime/debug"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestAutoTune(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		fsys fstest.MapFS
+		want Resources
+	}{
+		{"v2", fstest.MapFS{
+			"proc/self/cgroup":                       {Data: []byte("0::/kubepods/pod1\n")},
+			"sys/fs/cgroup/kubepods/pod1/cpu.max":    {Data: []byte("250000 100000\n")},
+			"sys/fs/cgroup/kubepods/pod1/memory.max": {Data: []byte("1073741824\n")},
+		}, Resources{Cgroup: 2, CPUQuota: 2.5, MemoryLimit: 1 << 30}},
+		{"v2 unlimited", fstest.MapFS{
+			"proc/self/cgroup":         {Data: []byte("0::/\n")},
+			"sys/fs/cgroup/cpu.max":    {Data: []byte("max 100000\n")},
+			"sys/fs/cgroup/memory.max": {Data: []byte("max\n")},
+		}, Resources{Cgroup: 2}},
+		{"v1", fstest.MapFS{
+			"proc/self/cgroup":                            {Data: []byte("4:memory:/docker/x\n3:cpu,cpuacct:/docker/x\n")},
+			"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_quota_us":  {Data: []byte("100000\n")},
+			"sys/fs/cgroup/cpu,cpuacct/cpu.cfs_period_us": {Data: []byte("100000\n")},
+			"sys/fs/cgroup/memory/memory.limit_in_bytes":  {Data: []byte("536870912\n")},
+		}, Resources{Cgroup: 1, CPUQuota: 1, MemoryLimit: 512 << 20}},
+		{"none", fstest.MapFS{}, Resources{}},
+	} {
+		if res, err := ReadResources(c.fsys); err != nil || res != c.want {
+			t.Errorf("%s: resources = %+v, %v, want %+v", c.name, res, err, c.want)
+		}
+	}
+
+	t.Setenv("GOMAXPROCS", "")
+	t.Setenv("GOMEMLIMIT", "")
+	procs, limit := goruntime.GOMAXPROCS(0), debug.SetMemoryLimit(-1)
+	t.Cleanup(func() {
+		goruntime.GOMAXPROCS(procs)
+		debug.SetMemoryLimit(limit)
+	})
+
+	r := New()
+	err := r.AutoTune(TuneFS(fstest.MapFS{
+		"proc/self/cgroup":         {Data: []byte("0::/\n")},
+		"sys/fs/cgroup/cpu.max":    {Data: []byte("150000 100000\n")},
+		"sys/fs/cgroup/memory.max": {Data: []byte("1000000\n")},
+	}), MemoryHeadroom(0.2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err, _ := r.Start(context.Background(), nil, nil, "", "", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	defer r.Dispose()
+
+	tuning := r.Tuning()
+	if tuning.GOMAXPROCS != 1 || goruntime.GOMAXPROCS(0) != 1 {
+		t.Errorf("GOMAXPROCS = %d (%d), want 1", tuning.GOMAXPROCS, goruntime.GOMAXPROCS(0))
+	}
+	if tuning.SoftMemoryLimit != 800000 || debug.SetMemoryLimit(-1) != 800000 {
+		t.Errorf("soft memory limit = %d, want 800000", tuning.SoftMemoryLimit)
+	}
+	if tuning.PrevGOMAXPROCS != procs || tuning.CPUQuota != 1.5 {
+		t.Errorf("tuning = %+v", tuning)
+	}
+}